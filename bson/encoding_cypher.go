@@ -2,8 +2,6 @@ package bson
 
 import (
 	"errors"
-	"fmt"
-	"math/big"
 
 	"github.com/keep-network/paillier"
 	"gopkg.in/mgo.v2/bson"
@@ -37,11 +35,11 @@ func toOriginalCypher(serializable *SerializableCypher) *paillier.Cypher {
 }
 
 type dbCypher struct {
-	C string
+	C hexOrDecimal
 }
 
 func (cypher *SerializableCypher) GetBSON() (interface{}, error) {
-	return &dbCypher{fmt.Sprintf("%x", cypher.C)}, nil
+	return &dbCypher{hexOrDecimal(toHex(cypher.C))}, nil
 }
 
 func (cypher *SerializableCypher) SetBSON(raw bson.Raw) error {
@@ -50,7 +48,7 @@ func (cypher *SerializableCypher) SetBSON(raw bson.Raw) error {
 		return err
 	}
 	var ok bool
-	cypher.C, ok = new(big.Int).SetString(c.C, 16)
+	cypher.C, ok = parseHex(string(c.C))
 	if !ok {
 		return errors.New("big int not in hexadecimal format")
 	}
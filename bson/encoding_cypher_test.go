@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/keep-network/paillier"
+	"gopkg.in/mgo.v2/bson"
 )
 
 func TestCypherBsonSerialization(t *testing.T) {
@@ -30,3 +31,88 @@ func TestCypherBsonSerialization(t *testing.T) {
 		)
 	}
 }
+
+func TestCypherBsonPrefixedHexadecimal(t *testing.T) {
+	serialized, err := bson.Marshal(&dbCypher{"0x1f"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deserialized, err := DeserializeCypher(serialized)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := &paillier.Cypher{C: b(31)}
+	if !reflect.DeepEqual(expected, deserialized) {
+		t.Errorf(
+			"Unexpected deserialization result\nActual: %v\nExpected: %v\n",
+			deserialized,
+			expected,
+		)
+	}
+}
+
+func TestCypherBsonSerializationOfZero(t *testing.T) {
+	cypher := &paillier.Cypher{C: b(0)}
+
+	serialized, err := SerializeCypher(cypher)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deserialized, err := DeserializeCypher(serialized)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if deserialized.C == nil || deserialized.C.Sign() != 0 {
+		t.Errorf(
+			"Unexpected serialization result\nActual: %v\nExpected: %v\n",
+			deserialized,
+			cypher,
+		)
+	}
+}
+
+func TestCypherBsonDeserializationOfEmptyC(t *testing.T) {
+	serialized, err := SerializeCypher(&paillier.Cypher{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := DeserializeCypher(serialized); err == nil {
+		t.Error("expected an error for an empty C")
+	}
+}
+
+func TestCypherBsonHexOrDecimal(t *testing.T) {
+	expected := &paillier.Cypher{C: b(31)}
+
+	var tests = map[string]map[string]interface{}{
+		"hexadecimal": {"c": "1f"},
+		"decimal":     {"c": 31},
+	}
+
+	for testName, db := range tests {
+		t.Run(testName, func(t *testing.T) {
+			serialized, err := bson.Marshal(db)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			deserialized, err := DeserializeCypher(serialized)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(expected, deserialized) {
+				t.Errorf(
+					"Unexpected deserialization result\nActual: %v\nExpected: %v\n",
+					deserialized,
+					expected,
+				)
+			}
+		})
+	}
+}
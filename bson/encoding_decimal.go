@@ -0,0 +1,68 @@
+package bson
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/keep-network/paillier"
+)
+
+// The serialization functions below encode big integers as base-10 strings
+// in JSON, for human-readable configuration and interoperability with
+// libraries emitting decimal big integers. Hexadecimal remains the default
+// representation used by all the other serialization functions.
+
+type decimalPublicKey struct {
+	N string `json:"n"`
+}
+
+type decimalCypher struct {
+	C string `json:"c"`
+}
+
+// Serializes PublicKey to JSON with decimal big integers
+func MarshalPublicKeyDecimal(publicKey *paillier.PublicKey) ([]byte, error) {
+	return json.Marshal(&decimalPublicKey{publicKey.N.String()})
+}
+
+// Deserializes JSON with decimal big integers to PublicKey
+func ParsePublicKeyDecimal(data []byte) (*paillier.PublicKey, error) {
+	decimal := new(decimalPublicKey)
+	if err := json.Unmarshal(data, decimal); err != nil {
+		return nil, err
+	}
+
+	n, err := fromDecimal(decimal.N)
+	if err != nil {
+		return nil, err
+	}
+	return &paillier.PublicKey{N: n}, nil
+}
+
+// Serializes Cypher to JSON with decimal big integers
+func MarshalCypherDecimal(cypher *paillier.Cypher) ([]byte, error) {
+	return json.Marshal(&decimalCypher{cypher.C.String()})
+}
+
+// Deserializes JSON with decimal big integers to Cypher
+func ParseCypherDecimal(data []byte) (*paillier.Cypher, error) {
+	decimal := new(decimalCypher)
+	if err := json.Unmarshal(data, decimal); err != nil {
+		return nil, err
+	}
+
+	c, err := fromDecimal(decimal.C)
+	if err != nil {
+		return nil, err
+	}
+	return &paillier.Cypher{C: c}, nil
+}
+
+func fromDecimal(decimal string) (*big.Int, error) {
+	n, ok := new(big.Int).SetString(decimal, 10)
+	if !ok {
+		return nil, fmt.Errorf("Cannot convert %s to int as decimal", decimal)
+	}
+	return n, nil
+}
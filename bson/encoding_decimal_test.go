@@ -0,0 +1,97 @@
+package bson
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/keep-network/paillier"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestPublicKeyDecimalSerialization(t *testing.T) {
+	key := &paillier.PublicKey{N: b(345)}
+
+	serialized, err := MarshalPublicKeyDecimal(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(serialized) != `{"n":"345"}` {
+		t.Errorf("Unexpected serialization result [%s]", serialized)
+	}
+
+	deserialized, err := ParsePublicKeyDecimal(serialized)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(key, deserialized) {
+		t.Errorf(
+			"Unexpected serialization result\nActual: %v\nExpected: %v\n",
+			deserialized,
+			key,
+		)
+	}
+
+	if _, err := ParsePublicKeyDecimal([]byte(`{"n":"159"}`)); err != nil {
+		t.Error(err)
+	}
+	if _, err := ParsePublicKeyDecimal([]byte(`{"n":"15f"}`)); err == nil {
+		t.Error("expected an error for a non-decimal N")
+	}
+}
+
+func TestPublicKeyDecimalMatchesHex(t *testing.T) {
+	key := &paillier.PublicKey{N: b(123456789)}
+
+	hexSerialized, err := SerializePublicKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hexEncoded := make(map[string]string)
+	if err := bson.Unmarshal(hexSerialized, &hexEncoded); err != nil {
+		t.Fatal(err)
+	}
+	hexN, err := fromHex(hexEncoded["n"])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decimalSerialized, err := MarshalPublicKeyDecimal(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decimalKey, err := ParsePublicKeyDecimal(decimalSerialized)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if hexN.Cmp(decimalKey.N) != 0 {
+		t.Errorf(
+			"Unexpected decimal N\nActual: %v\nExpected: %v\n",
+			decimalKey.N,
+			hexN,
+		)
+	}
+}
+
+func TestCypherDecimalSerialization(t *testing.T) {
+	cypher := &paillier.Cypher{C: b(98765)}
+
+	serialized, err := MarshalCypherDecimal(cypher)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deserialized, err := ParseCypherDecimal(serialized)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(cypher, deserialized) {
+		t.Errorf(
+			"Unexpected serialization result\nActual: %v\nExpected: %v\n",
+			deserialized,
+			cypher,
+		)
+	}
+}
@@ -0,0 +1,110 @@
+package bson
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/keep-network/paillier"
+)
+
+// Key as exported by other Paillier libraries, such as python-paillier (phe)
+// or Java implementations. Big integers are either JSON strings holding the
+// base64 (standard or URL alphabet, padded or not) encoding of the big-endian
+// bytes, or plain JSON numbers holding the decimal value.
+//
+// The private key is described either with `lambda` and optional `mu` or with
+// the primes `p` and `q`, as phe does. The public key can be nested in `pub`.
+// The generator is always assumed to be `g = n+1`.
+type interopKey struct {
+	N      json.RawMessage `json:"n"`
+	Lambda json.RawMessage `json:"lambda"`
+	Mu     json.RawMessage `json:"mu"`
+	P      json.RawMessage `json:"p"`
+	Q      json.RawMessage `json:"q"`
+	Pub    *interopKey     `json:"pub"`
+}
+
+// Parses a PublicKey exported in JSON by another Paillier library.
+func ParseInteropPublicKeyJSON(data []byte) (*paillier.PublicKey, error) {
+	key := new(interopKey)
+	if err := json.Unmarshal(data, key); err != nil {
+		return nil, err
+	}
+
+	n, err := key.modulus()
+	if err != nil {
+		return nil, err
+	}
+	return &paillier.PublicKey{N: n}, nil
+}
+
+// Parses a PrivateKey exported in JSON by another Paillier library.
+func ParseInteropPrivateKeyJSON(data []byte) (*paillier.PrivateKey, error) {
+	key := new(interopKey)
+	if err := json.Unmarshal(data, key); err != nil {
+		return nil, err
+	}
+
+	if key.Lambda == nil {
+		if key.P == nil || key.Q == nil {
+			return nil, errors.New("either lambda or p and q must be specified")
+		}
+		p, err := fromInterop(key.P)
+		if err != nil {
+			return nil, err
+		}
+		q, err := fromInterop(key.Q)
+		if err != nil {
+			return nil, err
+		}
+		return paillier.CreatePrivateKeyLCM(p, q), nil
+	}
+
+	n, err := key.modulus()
+	if err != nil {
+		return nil, err
+	}
+	privateKey := &paillier.PrivateKey{PublicKey: paillier.PublicKey{N: n}}
+	if privateKey.Lambda, err = fromInterop(key.Lambda); err != nil {
+		return nil, err
+	}
+	if key.Mu != nil {
+		if privateKey.Mu, err = fromInterop(key.Mu); err != nil {
+			return nil, err
+		}
+	}
+	return privateKey, nil
+}
+
+func (key *interopKey) modulus() (*big.Int, error) {
+	if key.N != nil {
+		return fromInterop(key.N)
+	}
+	if key.Pub != nil && key.Pub.N != nil {
+		return fromInterop(key.Pub.N)
+	}
+	return nil, errors.New("n must be specified")
+}
+
+func fromInterop(raw json.RawMessage) (*big.Int, error) {
+	var encoded string
+	if err := json.Unmarshal(raw, &encoded); err != nil {
+		n, ok := new(big.Int).SetString(string(raw), 10)
+		if !ok {
+			return nil, fmt.Errorf("Cannot convert %s to int", raw)
+		}
+		return n, nil
+	}
+
+	encoded = strings.TrimRight(encoded, "=")
+	encoded = strings.NewReplacer("+", "-", "/", "_").Replace(encoded)
+	bytes, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot convert %s to int as base64", raw)
+	}
+	return new(big.Int).SetBytes(bytes), nil
+}
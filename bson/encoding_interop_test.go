@@ -0,0 +1,87 @@
+package bson
+
+import (
+	"crypto/rand"
+	"reflect"
+	"testing"
+
+	"github.com/keep-network/paillier"
+)
+
+// Keys for p = 13, q = 11 in the python-paillier (phe) JWK-like format.
+var phePublicKey = []byte(`{
+	"kty": "DAJ",
+	"alg": "PAI-GN1",
+	"key_ops": ["encrypt"],
+	"n": "jw",
+	"kid": "Paillier public key"
+}`)
+
+var phePrivateKey = []byte(`{
+	"kty": "DAJ",
+	"key_ops": ["decrypt"],
+	"p": "DQ",
+	"q": "Cw",
+	"pub": {
+		"kty": "DAJ",
+		"alg": "PAI-GN1",
+		"key_ops": ["encrypt"],
+		"n": "jw",
+		"kid": "Paillier public key"
+	},
+	"kid": "Paillier private key"
+}`)
+
+func TestParseInteropPublicKeyJSON(t *testing.T) {
+	expected := &paillier.PublicKey{N: b(143)}
+
+	for _, data := range [][]byte{
+		phePublicKey,
+		[]byte(`{"n": "jw=="}`),
+		[]byte(`{"n": 143}`),
+	} {
+		key, err := ParseInteropPublicKeyJSON(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(expected, key) {
+			t.Errorf(
+				"Unexpected parsing result\nActual: %v\nExpected: %v\n",
+				key,
+				expected,
+			)
+		}
+	}
+
+	if _, err := ParseInteropPublicKeyJSON([]byte(`{"n": "not base64!"}`)); err == nil {
+		t.Error("expected an error for malformed n")
+	}
+}
+
+func TestParseInteropPrivateKeyJSON(t *testing.T) {
+	for _, data := range [][]byte{
+		phePrivateKey,
+		[]byte(`{"n": 143, "lambda": 60, "mu": 31}`),
+		[]byte(`{"pub": {"n": "jw"}, "lambda": "PA"}`),
+	} {
+		key, err := ParseInteropPrivateKeyJSON(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if key.N.Cmp(b(143)) != 0 {
+			t.Errorf("Unexpected N [%v]", key.N)
+		}
+
+		cypher, err := key.Encrypt(b(42), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m := key.Decrypt(cypher); m.Cmp(b(42)) != 0 {
+			t.Errorf("Unexpected decrypted value [%v]", m)
+		}
+	}
+
+	if _, err := ParseInteropPrivateKeyJSON([]byte(`{"n": 143}`)); err == nil {
+		t.Error("expected an error for missing lambda")
+	}
+}
@@ -1,6 +1,7 @@
 package bson
 
 import (
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -42,6 +43,42 @@ func JsonDeserializePartialDecryptionZKP(data []byte) (*paillier.PartialDecrypti
 	return toOriginalPartialDecryptionZKP(serializable), nil
 }
 
+// Serializes PartialDecryptionZKP to canonical JSON: logically equal proofs
+// are always serialized to the same bytes, so that the JSON can be signed or
+// hashed. The fields are always written in the same order, numbers are
+// written as lowercase hexadecimal strings without leading zeros, `Vi` keeps
+// its order and is `[]` when empty, and the label is omitted when empty.
+func CanonicalJsonSerializePartialDecryptionZKP(pdzkp *paillier.PartialDecryptionZKP) ([]byte, error) {
+	if pdzkp.Key == nil || pdzkp.Key.N == nil || pdzkp.Key.V == nil ||
+		pdzkp.Decryption == nil || pdzkp.E == nil || pdzkp.Z == nil || pdzkp.C == nil {
+		return nil, errors.New("partial decryption ZKP is incomplete")
+	}
+	for _, vi := range pdzkp.Key.Vi {
+		if vi == nil {
+			return nil, errors.New("partial decryption ZKP is incomplete")
+		}
+	}
+
+	db := new(dbPartialDecryptionZKP)
+	db.fromPartialDecryptionZKP(toSerializablePartialDecryptionZKP(pdzkp))
+	return json.Marshal(db)
+}
+
+// Deserializes canonical JSON produced by
+// `CanonicalJsonSerializePartialDecryptionZKP` to PartialDecryptionZKP
+func CanonicalJsonDeserializePartialDecryptionZKP(data []byte) (*paillier.PartialDecryptionZKP, error) {
+	db := new(dbPartialDecryptionZKP)
+	if err := json.Unmarshal(data, db); err != nil {
+		return nil, err
+	}
+
+	serializable := new(SerializablePartialDecryptionZKP)
+	if err := db.toPartialDecryptionZKP(serializable); err != nil {
+		return nil, err
+	}
+	return toOriginalPartialDecryptionZKP(serializable), nil
+}
+
 func toSerializablePartialDecryptionZKP(pdzkp *paillier.PartialDecryptionZKP) *SerializablePartialDecryptionZKP {
 	serializable := SerializablePartialDecryptionZKP(*pdzkp)
 	return &serializable
@@ -53,16 +90,17 @@ func toOriginalPartialDecryptionZKP(serializable *SerializablePartialDecryptionZ
 }
 
 type dbPartialDecryptionZKP struct {
-	Z                              string   `json:"z"`
-	E                              string   `json:"e"`
-	C                              string   `json:"c"`
-	V                              string   `json:"v"`
-	N                              string   `json:"n"`
-	Vi                             []string `json:"vi"`
-	Decryption                     string   `json:"decryption"`
-	Id                             int      `json:"id"`
-	TotalNumberOfDecryptionServers int      `json:"total_number_of_decryption_servers"`
-	Threshold                      int      `json:"threshold"`
+	Z                              hexOrDecimal   `json:"z"`
+	E                              hexOrDecimal   `json:"e"`
+	C                              hexOrDecimal   `json:"c"`
+	V                              hexOrDecimal   `json:"v"`
+	N                              hexOrDecimal   `json:"n"`
+	Vi                             []hexOrDecimal `json:"vi"`
+	Decryption                     hexOrDecimal   `json:"decryption"`
+	Id                             int            `json:"id"`
+	TotalNumberOfDecryptionServers int            `json:"total_number_of_decryption_servers"`
+	Threshold                      int            `json:"threshold"`
+	Label                          string         `json:"label,omitempty" bson:",omitempty"`
 }
 
 func (pdzkp *SerializablePartialDecryptionZKP) GetBSON() (interface{}, error) {
@@ -83,16 +121,17 @@ func (dbPDZKP *dbPartialDecryptionZKP) fromPartialDecryptionZKP(pdzkp *Serializa
 	dbPDZKP.Id = pdzkp.Id
 	dbPDZKP.TotalNumberOfDecryptionServers = pdzkp.Key.TotalNumberOfDecryptionServers
 	dbPDZKP.Threshold = pdzkp.Key.Threshold
-	dbPDZKP.Z = fmt.Sprintf("%x", pdzkp.Z)
-	dbPDZKP.E = fmt.Sprintf("%x", pdzkp.E)
-	dbPDZKP.N = fmt.Sprintf("%x", pdzkp.Key.N)
-	dbPDZKP.C = fmt.Sprintf("%x", pdzkp.C)
-	dbPDZKP.V = fmt.Sprintf("%x", pdzkp.Key.V)
-	dbPDZKP.Decryption = fmt.Sprintf("%x", pdzkp.Decryption)
-	dbPDZKP.Vi = make([]string, len(pdzkp.Key.Vi))
+	dbPDZKP.Z = hexOrDecimal(toHex(pdzkp.Z))
+	dbPDZKP.E = hexOrDecimal(toHex(pdzkp.E))
+	dbPDZKP.N = hexOrDecimal(toHex(pdzkp.Key.N))
+	dbPDZKP.C = hexOrDecimal(toHex(pdzkp.C))
+	dbPDZKP.V = hexOrDecimal(toHex(pdzkp.Key.V))
+	dbPDZKP.Decryption = hexOrDecimal(toHex(pdzkp.Decryption))
+	dbPDZKP.Vi = make([]hexOrDecimal, len(pdzkp.Key.Vi))
 	for i, vi := range pdzkp.Key.Vi {
-		dbPDZKP.Vi[i] = fmt.Sprintf("%x", vi)
+		dbPDZKP.Vi[i] = hexOrDecimal(toHex(vi))
 	}
+	dbPDZKP.Label = fmt.Sprintf("%x", pdzkp.Label)
 }
 
 func (dbPDZKP *dbPartialDecryptionZKP) toPartialDecryptionZKP(pdzkp *SerializablePartialDecryptionZKP) error {
@@ -103,12 +142,12 @@ func (dbPDZKP *dbPartialDecryptionZKP) toPartialDecryptionZKP(pdzkp *Serializabl
 	pdzkp.Id = dbPDZKP.Id
 	pdzkp.Key.TotalNumberOfDecryptionServers = dbPDZKP.TotalNumberOfDecryptionServers
 	pdzkp.Key.Threshold = dbPDZKP.Threshold
-	pdzkp.Z, oks[0] = new(big.Int).SetString(dbPDZKP.Z, 16)
-	pdzkp.E, oks[1] = new(big.Int).SetString(dbPDZKP.E, 16)
-	pdzkp.C, oks[2] = new(big.Int).SetString(dbPDZKP.C, 16)
-	pdzkp.Key.V, oks[3] = new(big.Int).SetString(dbPDZKP.V, 16)
-	pdzkp.Key.N, oks[4] = new(big.Int).SetString(dbPDZKP.N, 16)
-	pdzkp.Decryption, oks[5] = new(big.Int).SetString(dbPDZKP.Decryption, 16)
+	pdzkp.Z, oks[0] = parseHex(string(dbPDZKP.Z))
+	pdzkp.E, oks[1] = parseHex(string(dbPDZKP.E))
+	pdzkp.C, oks[2] = parseHex(string(dbPDZKP.C))
+	pdzkp.Key.V, oks[3] = parseHex(string(dbPDZKP.V))
+	pdzkp.Key.N, oks[4] = parseHex(string(dbPDZKP.N))
+	pdzkp.Decryption, oks[5] = parseHex(string(dbPDZKP.Decryption))
 
 	if !all(oks) {
 		fmt.Println(oks)
@@ -119,11 +158,19 @@ func (dbPDZKP *dbPartialDecryptionZKP) toPartialDecryptionZKP(pdzkp *Serializabl
 	pdzkp.Key.Vi = make([]*big.Int, len(dbPDZKP.Vi))
 	var ok bool
 	for i, vi := range dbPDZKP.Vi {
-		pdzkp.Key.Vi[i], ok = new(big.Int).SetString(vi, 16)
+		pdzkp.Key.Vi[i], ok = parseHex(string(vi))
 		if !ok {
 			return errors.New("numbers not in hexadecimal format")
 		}
 	}
 
+	if dbPDZKP.Label != "" {
+		label, err := hex.DecodeString(dbPDZKP.Label)
+		if err != nil {
+			return errors.New("label not in hexadecimal format")
+		}
+		pdzkp.Label = label
+	}
+
 	return nil
 }
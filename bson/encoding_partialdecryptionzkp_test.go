@@ -1,11 +1,14 @@
 package bson
 
 import (
+	"bytes"
+	"encoding/json"
 	"math/big"
 	"reflect"
 	"testing"
 
 	"github.com/keep-network/paillier"
+	"gopkg.in/mgo.v2/bson"
 )
 
 var pdzkp = &paillier.PartialDecryptionZKP{
@@ -66,3 +69,312 @@ func TestPdzkpJsonSerialization(t *testing.T) {
 		)
 	}
 }
+
+func TestLabelledPdzkpJsonSerialization(t *testing.T) {
+	labelled := *pdzkp
+	labelled.Label = []byte("request-42")
+
+	serialized, err := JsonSerializePartialDecryptionZKP(&labelled)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deserialized, err := JsonDeserializePartialDecryptionZKP(serialized)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(&labelled, deserialized) {
+		t.Errorf(
+			"Unexpected serialization result\nActual: %v\nExpected: %v\n",
+			deserialized,
+			&labelled,
+		)
+	}
+}
+
+func TestPdzkpCanonicalJsonSerialization(t *testing.T) {
+	serialized, err := CanonicalJsonSerializePartialDecryptionZKP(pdzkp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{"z":"58","e":"70","c":"63","v":"65","n":"159","vi":["4d","43"],` +
+		`"decryption":"ab","id":1,"total_number_of_decryption_servers":7,"threshold":98}`
+	if string(serialized) != expected {
+		t.Errorf(
+			"Unexpected serialization result\nActual: %s\nExpected: %s\n",
+			serialized,
+			expected,
+		)
+	}
+
+	deserialized, err := CanonicalJsonDeserializePartialDecryptionZKP(serialized)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(pdzkp, deserialized) {
+		t.Errorf(
+			"Unexpected deserialization result\nActual: %v\nExpected: %v\n",
+			deserialized,
+			pdzkp,
+		)
+	}
+}
+
+func TestPdzkpCanonicalJsonOfEqualProofs(t *testing.T) {
+	// Equal to `pdzkp` but built differently: numbers parsed from upper case
+	// hexadecimal strings with leading zeros and an empty, non-nil label.
+	parse := func(hex string) *big.Int {
+		n, ok := new(big.Int).SetString(hex, 16)
+		if !ok {
+			t.Fatalf("invalid hexadecimal %v", hex)
+		}
+		return n
+	}
+	equal := &paillier.PartialDecryptionZKP{
+		PartialDecryption: paillier.PartialDecryption{
+			Id:         1,
+			Decryption: parse("00AB"),
+		},
+		Key: &paillier.ThresholdPublicKey{
+			PublicKey: paillier.PublicKey{
+				N: parse("0159"),
+			},
+			TotalNumberOfDecryptionServers: 7,
+			Threshold:                      98,
+			V:                              parse("0065"),
+			Vi:                             []*big.Int{parse("4D"), parse("043")},
+		},
+		E:     parse("0070"),
+		Z:     parse("58"),
+		C:     parse("063"),
+		Label: []byte{},
+	}
+
+	serialized1, err := CanonicalJsonSerializePartialDecryptionZKP(pdzkp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serialized2, err := CanonicalJsonSerializePartialDecryptionZKP(equal)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(serialized1, serialized2) {
+		t.Errorf(
+			"Unexpected serialization result\nActual: %s\nExpected: %s\n",
+			serialized2,
+			serialized1,
+		)
+	}
+}
+
+func TestPdzkpCanonicalJsonOfIncompleteProof(t *testing.T) {
+	incomplete := *pdzkp
+	incomplete.E = nil
+
+	if _, err := CanonicalJsonSerializePartialDecryptionZKP(&incomplete); err == nil {
+		t.Error("expected an error for an incomplete proof")
+	}
+}
+
+func TestPdzkpSerializationOfZeroValues(t *testing.T) {
+	zero := *pdzkp
+	zero.E = b(0)
+	zero.Decryption = b(0)
+
+	var tests = map[string]struct {
+		pdzkp       *paillier.PartialDecryptionZKP
+		serialize   func(*paillier.PartialDecryptionZKP) ([]byte, error)
+		deserialize func([]byte) (*paillier.PartialDecryptionZKP, error)
+	}{
+		"bson with zero E and Decryption": {
+			pdzkp:       &zero,
+			serialize:   SerializePartialDecryptionZKP,
+			deserialize: DeserializePartialDecryptionZKP,
+		},
+		"json with zero E and Decryption": {
+			pdzkp:       &zero,
+			serialize:   JsonSerializePartialDecryptionZKP,
+			deserialize: JsonDeserializePartialDecryptionZKP,
+		},
+		"canonical json with zero E and Decryption": {
+			pdzkp:       &zero,
+			serialize:   CanonicalJsonSerializePartialDecryptionZKP,
+			deserialize: CanonicalJsonDeserializePartialDecryptionZKP,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			serialized, err := test.serialize(test.pdzkp)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			deserialized, err := test.deserialize(serialized)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !equalPdzkp(test.pdzkp, deserialized) {
+				t.Errorf(
+					"Unexpected serialization result\nActual: %v\nExpected: %v\n",
+					deserialized,
+					test.pdzkp,
+				)
+			}
+		})
+	}
+}
+
+func TestPdzkpDeserializationOfEmptyRequiredField(t *testing.T) {
+	withoutE := *pdzkp
+	withoutE.E = nil
+
+	key := *pdzkp.Key
+	key.V = nil
+	withoutV := *pdzkp
+	withoutV.Key = &key
+
+	key = *pdzkp.Key
+	key.Vi = []*big.Int{b(1), nil}
+	withoutVi := *pdzkp
+	withoutVi.Key = &key
+
+	// `CanonicalJsonSerializePartialDecryptionZKP` refuses incomplete proofs.
+	serializeIncomplete := func(pdzkp *paillier.PartialDecryptionZKP) ([]byte, error) {
+		db := new(dbPartialDecryptionZKP)
+		db.fromPartialDecryptionZKP(toSerializablePartialDecryptionZKP(pdzkp))
+		return json.Marshal(db)
+	}
+
+	var tests = map[string]struct {
+		pdzkp       *paillier.PartialDecryptionZKP
+		serialize   func(*paillier.PartialDecryptionZKP) ([]byte, error)
+		deserialize func([]byte) (*paillier.PartialDecryptionZKP, error)
+	}{
+		"bson with empty E": {
+			pdzkp:       &withoutE,
+			serialize:   SerializePartialDecryptionZKP,
+			deserialize: DeserializePartialDecryptionZKP,
+		},
+		"canonical json with empty E": {
+			pdzkp:       &withoutE,
+			serialize:   serializeIncomplete,
+			deserialize: CanonicalJsonDeserializePartialDecryptionZKP,
+		},
+		"bson with empty V": {
+			pdzkp:       &withoutV,
+			serialize:   SerializePartialDecryptionZKP,
+			deserialize: DeserializePartialDecryptionZKP,
+		},
+		"bson with empty Vi": {
+			pdzkp:       &withoutVi,
+			serialize:   SerializePartialDecryptionZKP,
+			deserialize: DeserializePartialDecryptionZKP,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			serialized, err := test.serialize(test.pdzkp)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := test.deserialize(serialized); err == nil {
+				t.Error("expected an error for an empty required field")
+			}
+		})
+	}
+}
+
+// Compares proofs by value. Unlike `reflect.DeepEqual`, it does not depend on
+// the internal representation of big integers, which differs for zero.
+func equalPdzkp(pdzkp1, pdzkp2 *paillier.PartialDecryptionZKP) bool {
+	equal := func(x, y *big.Int) bool {
+		if x == nil || y == nil {
+			return x == y
+		}
+		return x.Cmp(y) == 0
+	}
+
+	if pdzkp1.Id != pdzkp2.Id ||
+		!equal(pdzkp1.Decryption, pdzkp2.Decryption) ||
+		!equal(pdzkp1.E, pdzkp2.E) ||
+		!equal(pdzkp1.Z, pdzkp2.Z) ||
+		!equal(pdzkp1.C, pdzkp2.C) ||
+		!bytes.Equal(pdzkp1.Label, pdzkp2.Label) ||
+		!equal(pdzkp1.Key.N, pdzkp2.Key.N) ||
+		!equal(pdzkp1.Key.V, pdzkp2.Key.V) ||
+		pdzkp1.Key.Threshold != pdzkp2.Key.Threshold ||
+		pdzkp1.Key.TotalNumberOfDecryptionServers != pdzkp2.Key.TotalNumberOfDecryptionServers ||
+		len(pdzkp1.Key.Vi) != len(pdzkp2.Key.Vi) {
+		return false
+	}
+	for i := range pdzkp1.Key.Vi {
+		if !equal(pdzkp1.Key.Vi[i], pdzkp2.Key.Vi[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestPdzkpHexOrDecimal(t *testing.T) {
+	var tests = map[string]struct {
+		serialized  []byte
+		deserialize func([]byte) (*paillier.PartialDecryptionZKP, error)
+	}{
+		"canonical json hexadecimal": {
+			serialized: []byte(`{"z":"58","e":"70","c":"63","v":"65","n":"159",` +
+				`"vi":["4d","43"],"decryption":"ab","id":1,` +
+				`"total_number_of_decryption_servers":7,"threshold":98}`),
+			deserialize: CanonicalJsonDeserializePartialDecryptionZKP,
+		},
+		"canonical json decimal": {
+			serialized: []byte(`{"z":88,"e":112,"c":99,"v":101,"n":345,` +
+				`"vi":[77,67],"decryption":171,"id":1,` +
+				`"total_number_of_decryption_servers":7,"threshold":98}`),
+			deserialize: CanonicalJsonDeserializePartialDecryptionZKP,
+		},
+		"bson decimal": {
+			serialized: func() []byte {
+				serialized, err := SerializePartialDecryptionZKP(pdzkp)
+				if err != nil {
+					t.Fatal(err)
+				}
+				db := make(map[string]interface{})
+				if err := bson.Unmarshal(serialized, &db); err != nil {
+					t.Fatal(err)
+				}
+				db["z"], db["e"], db["c"] = 88, 112, 99
+				db["v"], db["n"], db["decryption"] = 101, 345, 171
+				db["vi"] = []interface{}{77, 67}
+				if serialized, err = bson.Marshal(db); err != nil {
+					t.Fatal(err)
+				}
+				return serialized
+			}(),
+			deserialize: DeserializePartialDecryptionZKP,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			deserialized, err := test.deserialize(test.serialized)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !equalPdzkp(pdzkp, deserialized) {
+				t.Errorf(
+					"Unexpected deserialization result\nActual: %v\nExpected: %v\n",
+					deserialized,
+					pdzkp,
+				)
+			}
+		})
+	}
+}
@@ -1,8 +1,6 @@
 package bson
 
 import (
-	"fmt"
-
 	"github.com/keep-network/paillier"
 	"gopkg.in/mgo.v2/bson"
 )
@@ -35,19 +33,22 @@ func toOriginalPrivateKey(serializable *SerializablePrivateKey) *paillier.Privat
 }
 
 type dbPrivateKey struct {
-	N      string `bson:",omitempty"`
-	Lambda string `bson:",omitempty"`
-	Mu     string `bson:",omitempty"`
+	N      hexOrDecimal `bson:",omitempty"`
+	Lambda hexOrDecimal `bson:",omitempty"`
+	Mu     hexOrDecimal `bson:",omitempty"`
 }
 
 func (privateKey *SerializablePrivateKey) GetBSON() (interface{}, error) {
 	m := make(map[string]string)
 
 	if privateKey.N != nil {
-		m["n"] = fmt.Sprintf("%x", privateKey.N)
+		m["n"] = toHex(privateKey.N)
 	}
 	if privateKey.Lambda != nil {
-		m["lambda"] = fmt.Sprintf("%x", privateKey.Lambda)
+		m["lambda"] = toHex(privateKey.Lambda)
+	}
+	if privateKey.Mu != nil {
+		m["mu"] = toHex(privateKey.Mu)
 	}
 	return m, nil
 }
@@ -58,14 +59,21 @@ func (privateKey *SerializablePrivateKey) SetBSON(raw bson.Raw) error {
 	raw.Unmarshal(c)
 
 	if c.N != "" {
-		privateKey.N, err = fromHex(c.N)
+		privateKey.N, err = fromHex(string(c.N))
 		if err != nil {
 			return err
 		}
 	}
 
 	if c.Lambda != "" {
-		privateKey.Lambda, err = fromHex(c.Lambda)
+		privateKey.Lambda, err = fromHex(string(c.Lambda))
+		if err != nil {
+			return err
+		}
+	}
+
+	if c.Mu != "" {
+		privateKey.Mu, err = fromHex(string(c.Mu))
 		if err != nil {
 			return err
 		}
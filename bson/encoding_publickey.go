@@ -1,8 +1,6 @@
 package bson
 
 import (
-	"fmt"
-
 	"github.com/keep-network/paillier"
 	"gopkg.in/mgo.v2/bson"
 )
@@ -35,12 +33,12 @@ func toOriginalPublicKey(serializable *SerializablePublicKey) *paillier.PublicKe
 }
 
 type dbPublicKey struct {
-	N string `bson:",omitempty"`
+	N hexOrDecimal `bson:",omitempty"`
 }
 
 func (publicKey *SerializablePublicKey) GetBSON() (interface{}, error) {
 	m := make(map[string]string)
-	m["n"] = fmt.Sprintf("%x", publicKey.N)
+	m["n"] = toHex(publicKey.N)
 	return m, nil
 }
 
@@ -50,7 +48,7 @@ func (publicKey *SerializablePublicKey) SetBSON(raw bson.Raw) error {
 	raw.Unmarshal(c)
 
 	if c.N != "" {
-		publicKey.N, err = fromHex(c.N)
+		publicKey.N, err = fromHex(string(c.N))
 		if err != nil {
 			return err
 		}
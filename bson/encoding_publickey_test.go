@@ -1,6 +1,7 @@
 package bson
 
 import (
+	"gopkg.in/mgo.v2/bson"
 	"reflect"
 	"testing"
 
@@ -30,3 +31,34 @@ func TestPublicKeyBsonSerialization(t *testing.T) {
 		)
 	}
 }
+
+func TestPublicKeyBsonHexOrDecimal(t *testing.T) {
+	expected := &paillier.PublicKey{N: b(345)}
+
+	var tests = map[string]map[string]interface{}{
+		"hexadecimal": {"n": "159"},
+		"decimal":     {"n": 345},
+	}
+
+	for testName, db := range tests {
+		t.Run(testName, func(t *testing.T) {
+			serialized, err := bson.Marshal(db)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			deserialized, err := DeserializePublicKey(serialized)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(expected, deserialized) {
+				t.Errorf(
+					"Unexpected deserialization result\nActual: %v\nExpected: %v\n",
+					deserialized,
+					expected,
+				)
+			}
+		})
+	}
+}
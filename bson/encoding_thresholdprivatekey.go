@@ -0,0 +1,189 @@
+package bson
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+
+	"github.com/keep-network/paillier"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// SerializableThresholdPrivateKey is a ThresholdPrivateKey of a decryption
+// server which can be serialized to BSON and JSON, including its `Id` and
+// secret `Share`. All numbers are encoded as hexadecimal strings.
+//
+// The serialized key contains the secret share of the server in plain text.
+// It must be stored and transferred with the same care as the key itself,
+// e.g. encrypted at rest and never logged.
+//
+// When deserializing, numbers may also be prefixed with `0x` or given in
+// decimal as plain JSON numbers or BSON integers, see `hexOrDecimal`.
+type SerializableThresholdPrivateKey paillier.ThresholdPrivateKey
+
+// Serializes ThresholdPrivateKey to BSON
+func SerializeThresholdPrivateKey(key *paillier.ThresholdPrivateKey) ([]byte, error) {
+	return bson.Marshal(toSerializableThresholdPrivateKey(key))
+}
+
+// Deserializes BSON to ThresholdPrivateKey
+func DeserializeThresholdPrivateKey(data []byte) (*paillier.ThresholdPrivateKey, error) {
+	serializable := new(SerializableThresholdPrivateKey)
+	if err := bson.Unmarshal(data, serializable); err != nil {
+		return nil, err
+	}
+
+	return toOriginalThresholdPrivateKey(serializable), nil
+}
+
+// Serializes ThresholdPrivateKey to JSON
+func JsonSerializeThresholdPrivateKey(key *paillier.ThresholdPrivateKey) ([]byte, error) {
+	return json.Marshal(toSerializableThresholdPrivateKey(key))
+}
+
+// Deserializes JSON to ThresholdPrivateKey
+func JsonDeserializeThresholdPrivateKey(data []byte) (*paillier.ThresholdPrivateKey, error) {
+	serializable := new(SerializableThresholdPrivateKey)
+	if err := json.Unmarshal(data, serializable); err != nil {
+		return nil, err
+	}
+
+	return toOriginalThresholdPrivateKey(serializable), nil
+}
+
+// Serializes the whole key set generated by a dealer to BSON, one blob per
+// decryption server to be sent to this server only. Each blob holds the
+// shared public key together with the `Id` and `Share` of the server and can
+// be deserialized with `DeserializeThresholdPrivateKey`.
+//
+// An error is returned and nothing is serialized if the keys do not share
+// the same public key.
+func SerializeThresholdKeySet(keys []*paillier.ThresholdPrivateKey) ([][]byte, error) {
+	if err := checkThresholdKeySet(keys); err != nil {
+		return nil, err
+	}
+
+	blobs := make([][]byte, len(keys))
+	for i, key := range keys {
+		blob, err := SerializeThresholdPrivateKey(key)
+		if err != nil {
+			return nil, err
+		}
+		blobs[i] = blob
+	}
+	return blobs, nil
+}
+
+// Deserializes the key set serialized with `SerializeThresholdKeySet`. An
+// error is returned if any of the blobs is invalid or if the keys do not share
+// the same public key.
+func DeserializeThresholdKeySet(blobs [][]byte) ([]*paillier.ThresholdPrivateKey, error) {
+	keys := make([]*paillier.ThresholdPrivateKey, len(blobs))
+	for i, blob := range blobs {
+		key, err := DeserializeThresholdPrivateKey(blob)
+		if err != nil {
+			return nil, err
+		}
+		keys[i] = key
+	}
+
+	if err := checkThresholdKeySet(keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func checkThresholdKeySet(keys []*paillier.ThresholdPrivateKey) error {
+	if len(keys) == 0 {
+		return errors.New("key set is empty")
+	}
+	for _, key := range keys[1:] {
+		if !key.ThresholdPublicKey.Equal(&keys[0].ThresholdPublicKey) {
+			return errors.New("keys of the set do not share the same public key")
+		}
+	}
+	return nil
+}
+
+func toSerializableThresholdPrivateKey(key *paillier.ThresholdPrivateKey) *SerializableThresholdPrivateKey {
+	serializable := SerializableThresholdPrivateKey(*key)
+	return &serializable
+}
+
+func toOriginalThresholdPrivateKey(serializable *SerializableThresholdPrivateKey) *paillier.ThresholdPrivateKey {
+	original := paillier.ThresholdPrivateKey(*serializable)
+	return &original
+}
+
+func (key *SerializableThresholdPrivateKey) GetBSON() (interface{}, error) {
+	db := new(dbThresholdPrivateKey)
+	db.fromThresholdPrivateKey(key)
+	return db, nil
+}
+
+func (key *SerializableThresholdPrivateKey) SetBSON(raw bson.Raw) error {
+	db := new(dbThresholdPrivateKey)
+	if err := raw.Unmarshal(db); err != nil {
+		return err
+	}
+	return db.toThresholdPrivateKey(key)
+}
+
+func (key *SerializableThresholdPrivateKey) MarshalJSON() ([]byte, error) {
+	db := new(dbThresholdPrivateKey)
+	db.fromThresholdPrivateKey(key)
+	return json.Marshal(db)
+}
+
+func (key *SerializableThresholdPrivateKey) UnmarshalJSON(data []byte) error {
+	db := new(dbThresholdPrivateKey)
+	if err := json.Unmarshal(data, db); err != nil {
+		return err
+	}
+	return db.toThresholdPrivateKey(key)
+}
+
+type dbThresholdPrivateKey struct {
+	TotalNumberOfDecryptionServers int            `json:"total_number_of_decryption_servers"`
+	Threshold                      int            `json:"threshold"`
+	V                              hexOrDecimal   `json:"v"`
+	Vi                             []hexOrDecimal `json:"vi"`
+	N                              hexOrDecimal   `json:"n"`
+	Id                             int            `json:"id"`
+	Share                          hexOrDecimal   `json:"share"`
+}
+
+func (db *dbThresholdPrivateKey) fromThresholdPrivateKey(key *SerializableThresholdPrivateKey) {
+	db.TotalNumberOfDecryptionServers = key.TotalNumberOfDecryptionServers
+	db.Threshold = key.Threshold
+	db.V = hexOrDecimal(toHex(key.V))
+	db.N = hexOrDecimal(toHex(key.N))
+	db.Vi = make([]hexOrDecimal, len(key.Vi))
+	for i, vi := range key.Vi {
+		db.Vi[i] = hexOrDecimal(toHex(vi))
+	}
+	db.Id = key.Id
+	db.Share = hexOrDecimal(toHex(key.Share))
+}
+
+func (db *dbThresholdPrivateKey) toThresholdPrivateKey(key *SerializableThresholdPrivateKey) error {
+	key.TotalNumberOfDecryptionServers = db.TotalNumberOfDecryptionServers
+	key.Threshold = db.Threshold
+	oks := make([]bool, 3)
+	key.V, oks[0] = parseHex(string(db.V))
+	key.N, oks[1] = parseHex(string(db.N))
+	key.Share, oks[2] = parseHex(string(db.Share))
+	if !all(oks) {
+		return errors.New("not hexadecimal")
+	}
+	key.Vi = make([]*big.Int, len(db.Vi))
+	var ok bool
+	for i, vi := range db.Vi {
+		key.Vi[i], ok = parseHex(string(vi))
+		if !ok {
+			return errors.New("not hexadecimal")
+		}
+	}
+	key.Id = db.Id
+	return nil
+}
@@ -0,0 +1,238 @@
+package bson
+
+import (
+	"crypto/rand"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/keep-network/paillier"
+)
+
+func TestThresholdPrivateKeySerialization(t *testing.T) {
+	key := &paillier.ThresholdPrivateKey{
+		ThresholdPublicKey: paillier.ThresholdPublicKey{
+			PublicKey:                      paillier.PublicKey{N: b(9)},
+			TotalNumberOfDecryptionServers: 7,
+			Threshold:                      6,
+			V:                              b(3),
+			Vi:                             []*big.Int{b(2), b(34)},
+		},
+		Id:    2,
+		Share: b(123),
+	}
+
+	var tests = map[string]struct {
+		serialize   func(*paillier.ThresholdPrivateKey) ([]byte, error)
+		deserialize func([]byte) (*paillier.ThresholdPrivateKey, error)
+	}{
+		"bson": {
+			serialize:   SerializeThresholdPrivateKey,
+			deserialize: DeserializeThresholdPrivateKey,
+		},
+		"json": {
+			serialize:   JsonSerializeThresholdPrivateKey,
+			deserialize: JsonDeserializeThresholdPrivateKey,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			serialized, err := test.serialize(key)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			deserialized, err := test.deserialize(serialized)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(key, deserialized) {
+				t.Errorf(
+					"Unexpected serialization result\nActual: %v\nExpected: %v\n",
+					deserialized,
+					key,
+				)
+			}
+		})
+	}
+}
+
+func TestThresholdPrivateKeyJsonDecrypts(t *testing.T) {
+	tkh, err := paillier.GetThresholdKeyGenerator(32, 3, 2, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tpks, err := tkh.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	serialized, err := JsonSerializeThresholdPrivateKey(tpks[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	deserialized, err := JsonDeserializeThresholdPrivateKey(serialized)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := tpks[0].Encrypt(b(100), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	share1, err := deserialized.DecryptAndProduceZKP(c.C, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !share1.Verify() {
+		t.Fatal("partial decryption of the deserialized key should be valid")
+	}
+	share2, err := tpks[1].DecryptAndProduceZKP(c.C, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	message, err := tpks[1].CombinePartialDecryptionsZKP(
+		[]*paillier.PartialDecryptionZKP{share1, share2},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if message.Cmp(b(100)) != 0 {
+		t.Errorf("Unexpected decrypted value [%v]", message)
+	}
+}
+
+func TestThresholdPrivateKeyJsonHexOrDecimal(t *testing.T) {
+	expected := &paillier.ThresholdPrivateKey{
+		ThresholdPublicKey: paillier.ThresholdPublicKey{
+			PublicKey:                      paillier.PublicKey{N: b(299)},
+			TotalNumberOfDecryptionServers: 7,
+			Threshold:                      6,
+			V:                              b(31),
+			Vi:                             []*big.Int{b(2), b(255)},
+		},
+		Id:    2,
+		Share: b(123),
+	}
+
+	var tests = map[string]string{
+		"hexadecimal": `{"total_number_of_decryption_servers":7,"threshold":6,` +
+			`"v":"1f","vi":["2","ff"],"n":"12b","id":2,"share":"7b"}`,
+		"prefixed hexadecimal": `{"total_number_of_decryption_servers":7,"threshold":6,` +
+			`"v":"0x1f","vi":["0x2","0XFF"],"n":"0x12b","id":2,"share":"0x7b"}`,
+		"decimal": `{"total_number_of_decryption_servers":7,"threshold":6,` +
+			`"v":31,"vi":[2,255],"n":299,"id":2,"share":123}`,
+		"mixed": `{"total_number_of_decryption_servers":7,"threshold":6,` +
+			`"v":31,"vi":["0x2",255],"n":"12b","id":2,"share":123}`,
+	}
+
+	for testName, serialized := range tests {
+		t.Run(testName, func(t *testing.T) {
+			deserialized, err := JsonDeserializeThresholdPrivateKey([]byte(serialized))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(expected, deserialized) {
+				t.Errorf(
+					"Unexpected deserialization result\nActual: %v\nExpected: %v\n",
+					deserialized,
+					expected,
+				)
+			}
+		})
+	}
+}
+
+func TestThresholdPrivateKeyJsonNotANumber(t *testing.T) {
+	serialized := `{"total_number_of_decryption_servers":7,"threshold":6,` +
+		`"v":true,"vi":[],"n":"12b","id":2,"share":"7b"}`
+
+	if _, err := JsonDeserializeThresholdPrivateKey([]byte(serialized)); err == nil {
+		t.Error("expected an error for a value which is not a number")
+	}
+}
+
+func TestThresholdKeySetSerialization(t *testing.T) {
+	tkh, err := paillier.GetThresholdKeyGenerator(32, 3, 2, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tpks, err := tkh.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	blobs, err := SerializeThresholdKeySet(tpks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(blobs) != 3 {
+		t.Fatalf("Unexpected number of blobs\nExpected: %v\nActual: %v", 3, len(blobs))
+	}
+
+	deserialized, err := DeserializeThresholdKeySet(blobs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(tpks, deserialized) {
+		t.Errorf(
+			"Unexpected deserialization result\nActual: %v\nExpected: %v\n",
+			deserialized,
+			tpks,
+		)
+	}
+
+	c, err := deserialized[0].Encrypt(b(100), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// every server decrypts together with the next one
+	for i, key := range deserialized {
+		next := deserialized[(i+1)%len(deserialized)]
+		message, err := key.CombinePartialDecryptions(
+			[]*paillier.PartialDecryption{key.Decrypt(c.C), next.Decrypt(c.C)},
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if message.Cmp(b(100)) != 0 {
+			t.Errorf("Unexpected decrypted value [%v]", message)
+		}
+	}
+}
+
+func TestThresholdKeySetOfDifferentKeys(t *testing.T) {
+	generate := func() []*paillier.ThresholdPrivateKey {
+		tkh, err := paillier.GetThresholdKeyGenerator(32, 2, 2, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tpks, err := tkh.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tpks
+	}
+	tpks := generate()
+	mixed := []*paillier.ThresholdPrivateKey{tpks[0], generate()[1]}
+
+	if _, err := SerializeThresholdKeySet(mixed); err == nil {
+		t.Error("expected an error for keys of different public keys")
+	}
+
+	blob1, err := SerializeThresholdPrivateKey(mixed[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	blob2, err := SerializeThresholdPrivateKey(mixed[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DeserializeThresholdKeySet([][]byte{blob1, blob2}); err == nil {
+		t.Error("expected an error for keys of different public keys")
+	}
+}
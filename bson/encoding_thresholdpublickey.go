@@ -2,7 +2,6 @@ package bson
 
 import (
 	"errors"
-	"fmt"
 	"math/big"
 
 	"github.com/keep-network/paillier"
@@ -53,19 +52,19 @@ func (thresholdPublicKey *SerializableThresholdPublicKey) SetBSON(raw bson.Raw)
 type dbThresholdKey struct {
 	TotalNumberOfDecryptionServers int
 	Threshold                      int
-	V                              string
-	Vi                             []string
-	N                              string
+	V                              hexOrDecimal
+	Vi                             []hexOrDecimal
+	N                              hexOrDecimal
 }
 
 func (dbThresholdKey *dbThresholdKey) fromThresholdPublicKey(key *SerializableThresholdPublicKey) {
 	dbThresholdKey.TotalNumberOfDecryptionServers = key.TotalNumberOfDecryptionServers
 	dbThresholdKey.Threshold = key.Threshold
-	dbThresholdKey.V = fmt.Sprintf("%x", key.V)
-	dbThresholdKey.N = fmt.Sprintf("%x", key.N)
-	dbThresholdKey.Vi = make([]string, len(key.Vi))
+	dbThresholdKey.V = hexOrDecimal(toHex(key.V))
+	dbThresholdKey.N = hexOrDecimal(toHex(key.N))
+	dbThresholdKey.Vi = make([]hexOrDecimal, len(key.Vi))
 	for i, vi := range key.Vi {
-		dbThresholdKey.Vi[i] = fmt.Sprintf("%x", vi)
+		dbThresholdKey.Vi[i] = hexOrDecimal(toHex(vi))
 	}
 }
 
@@ -73,15 +72,15 @@ func (dbThresholdKey *dbThresholdKey) toThresholdPublicKey(key *SerializableThre
 	key.TotalNumberOfDecryptionServers = dbThresholdKey.TotalNumberOfDecryptionServers
 	key.Threshold = dbThresholdKey.Threshold
 	oks := make([]bool, 2)
-	key.V, oks[0] = new(big.Int).SetString(dbThresholdKey.V, 16)
-	key.N, oks[1] = new(big.Int).SetString(dbThresholdKey.N, 16)
+	key.V, oks[0] = parseHex(string(dbThresholdKey.V))
+	key.N, oks[1] = parseHex(string(dbThresholdKey.N))
 	if !all(oks) {
 		return errors.New("not hexadecimal")
 	}
 	key.Vi = make([]*big.Int, len(dbThresholdKey.Vi))
 	var ok bool
 	for i, vi := range dbThresholdKey.Vi {
-		key.Vi[i], ok = new(big.Int).SetString(vi, 16)
+		key.Vi[i], ok = parseHex(string(vi))
 		if !ok {
 			return errors.New("not hexadecimal")
 		}
@@ -1,6 +1,7 @@
 package bson
 
 import (
+	"gopkg.in/mgo.v2/bson"
 	"math/big"
 	"reflect"
 	"testing"
@@ -35,3 +36,52 @@ func TestThresholdKeySerialization(t *testing.T) {
 		)
 	}
 }
+
+func TestThresholdKeyBsonHexOrDecimal(t *testing.T) {
+	expected := &paillier.ThresholdPublicKey{
+		PublicKey:                      paillier.PublicKey{N: b(299)},
+		TotalNumberOfDecryptionServers: 7,
+		Threshold:                      6,
+		V:                              b(31),
+		Vi:                             []*big.Int{b(2), b(255)},
+	}
+
+	var tests = map[string]map[string]interface{}{
+		"hexadecimal": {
+			"totalnumberofdecryptionservers": 7,
+			"threshold":                      6,
+			"v":                              "1f",
+			"vi":                             []interface{}{"2", "ff"},
+			"n":                              "12b",
+		},
+		"decimal": {
+			"totalnumberofdecryptionservers": 7,
+			"threshold":                      6,
+			"v":                              31,
+			"vi":                             []interface{}{2, 255},
+			"n":                              299,
+		},
+	}
+
+	for testName, db := range tests {
+		t.Run(testName, func(t *testing.T) {
+			serialized, err := bson.Marshal(db)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			deserialized, err := DeserializeThresholdPublicKey(serialized)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(expected, deserialized) {
+				t.Errorf(
+					"Unexpected deserialization result\nActual: %v\nExpected: %v\n",
+					deserialized,
+					expected,
+				)
+			}
+		})
+	}
+}
@@ -1,13 +1,43 @@
 package bson
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"math/big"
+	"strings"
+
+	"gopkg.in/mgo.v2/bson"
 )
 
+// Serializes a big integer as a lowercase hexadecimal string without leading
+// zeros. Zero is serialized as "0", which round-trips through `parseHex`, and
+// nil as the empty string, which `parseHex` rejects since all the big integers
+// serialized by this package are required.
+func toHex(n *big.Int) string {
+	if n == nil {
+		return ""
+	}
+	return fmt.Sprintf("%x", n)
+}
+
+// Parses a big integer serialized as a hexadecimal string. An optional `0x`
+// or `0X` prefix is accepted, e.g. for values written by other tools. Without
+// a prefix the string is always read as hexadecimal, as written by this
+// package, even if it contains only decimal digits. The empty string, written
+// by `toHex` for nil, is not accepted.
+func parseHex(hex string) (*big.Int, bool) {
+	if hex == "" {
+		return nil, false
+	}
+	if strings.HasPrefix(hex, "0x") || strings.HasPrefix(hex, "0X") {
+		hex = hex[2:]
+	}
+	return new(big.Int).SetString(hex, 16)
+}
+
 func fromHex(hex string) (*big.Int, error) {
-	n, err := new(big.Int).SetString(hex, 16)
+	n, err := parseHex(hex)
 	if !err {
 		msg := fmt.Sprintf("Cannot convert %s to int as hexadecimal", hex)
 		return nil, errors.New(msg)
@@ -15,6 +45,43 @@ func fromHex(hex string) (*big.Int, error) {
 	return n, nil
 }
 
+// A big integer serialized as a hexadecimal string, see `parseHex`. It is used
+// by all the decoders of this package. A plain JSON number holding the decimal
+// value, or a BSON integer, is accepted as well, as written by tools emitting
+// decimal big integers. Strings are always hexadecimal, since every decimal
+// string is also a valid hexadecimal one.
+type hexOrDecimal string
+
+func (h *hexOrDecimal) UnmarshalJSON(data []byte) error {
+	var hex string
+	if err := json.Unmarshal(data, &hex); err == nil {
+		*h = hexOrDecimal(hex)
+		return nil
+	}
+
+	n, ok := new(big.Int).SetString(string(data), 10)
+	if !ok {
+		return fmt.Errorf("Cannot convert %s to int as hexadecimal or decimal", data)
+	}
+	*h = hexOrDecimal(toHex(n))
+	return nil
+}
+
+func (h *hexOrDecimal) SetBSON(raw bson.Raw) error {
+	var hex string
+	if err := raw.Unmarshal(&hex); err == nil {
+		*h = hexOrDecimal(hex)
+		return nil
+	}
+
+	var n int64
+	if err := raw.Unmarshal(&n); err != nil {
+		return errors.New("Cannot convert BSON value to int as hexadecimal or decimal")
+	}
+	*h = hexOrDecimal(toHex(big.NewInt(n)))
+	return nil
+}
+
 func all(oks []bool) bool {
 	for _, ok := range oks {
 		if !ok {
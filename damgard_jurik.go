@@ -0,0 +1,262 @@
+package paillier
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// Public key for the Damgård–Jurik generalization of the Paillier scheme.
+//
+// Cyphertexts are computed modulo N^(S+1) and the plaintext space is Z_{N^S}
+// instead of Z_N. For S = 1 the scheme is the Paillier scheme. A bigger S
+// allows to encrypt much longer messages with the same modulus N, at the cost
+// of cyphertexts of (S+1)*|N| bits.
+//
+// The generator g is always equal N+1, see [DJN 10], section 3.
+//
+//     [DJN 10]: Ivan Damgard, Mads Jurik, Jesper Buus Nielsen, (2010)
+//               A Generalization of Paillier’s Public-Key System
+//               with Applications to Electronic Voting
+//               Aarhus University, Dept. of Computer Science, BRICS
+type DamgardJurikPublicKey struct {
+	N *big.Int
+	S int
+}
+
+// Returns N^S, the modulus of the plaintext space.
+func (pk *DamgardJurikPublicKey) GetNS() *big.Int {
+	return new(big.Int).Exp(pk.N, big.NewInt(int64(pk.S)), nil)
+}
+
+// Returns N^(S+1), the modulus of the cyphertext space.
+func (pk *DamgardJurikPublicKey) GetNSPlusOne() *big.Int {
+	return new(big.Int).Exp(pk.N, big.NewInt(int64(pk.S+1)), nil)
+}
+
+// PlaintextBits returns the number of bits which can always be encoded in
+// a plaintext, that is `S * (N.BitLen() - 1)`. See `PublicKey.PlaintextBits`.
+func (pk *DamgardJurikPublicKey) PlaintextBits() int {
+	return pk.S * (pk.N.BitLen() - 1)
+}
+
+// EncryptWithR encrypts a plaintext into a cypher one with random `r`
+// specified in the argument. The plaintext must be in [0, N^S) and `r` must be
+// an element of the multiplicative group of integers modulo N.
+//
+// E(m, r) = [(1 + N)^m r^(N^S)] mod N^(S+1)
+func (pk *DamgardJurikPublicKey) EncryptWithR(m *big.Int, r *big.Int) (*Cypher, error) {
+	ns := pk.GetNS()
+	if m.Cmp(ZERO) == -1 || m.Cmp(ns) != -1 { // m < 0 || m >= N^S  ?
+		return nil, fmt.Errorf(
+			"%v is out of allowed plaintext space [0, %v)",
+			m,
+			ns,
+		)
+	}
+	if r.Cmp(ONE) == -1 || r.Cmp(pk.N) != -1 { // r < 1 || r >= N  ?
+		return nil, fmt.Errorf("r is out of allowed range [1, %v)", pk.N)
+	}
+	if new(big.Int).GCD(nil, nil, r, pk.N).Cmp(ONE) != 0 {
+		return nil, errors.New("r is not invertible modulo N")
+	}
+
+	nsPlusOne := pk.GetNSPlusOne()
+	g := new(big.Int).Add(pk.N, ONE)
+	gm := new(big.Int).Exp(g, m, nsPlusOne)
+	rns := new(big.Int).Exp(r, ns, nsPlusOne)
+	return &Cypher{new(big.Int).Mod(new(big.Int).Mul(rns, gm), nsPlusOne)}, nil
+}
+
+// Encrypt a plaintext into a cypher one. The plaintext must be in [0, N^S).
+// random is usually rand.Reader from the package crypto/rand.
+//
+// E(m, r) = [(1 + N)^m r^(N^S)] mod N^(S+1)
+//
+// Returns an error if an error has be returned by RandReader.
+func (pk *DamgardJurikPublicKey) Encrypt(m *big.Int, random RandReader) (*Cypher, error) {
+	r, err := GetRandomNumberInMultiplicativeGroup(pk.N, random)
+	if err != nil {
+		return nil, err
+	}
+
+	return pk.EncryptWithR(m, r)
+}
+
+// RequiredS returns the smallest S for which the plaintext `m` fits in the
+// plaintext space Z_{N^S} of a Damgård–Jurik key with the modulus N.
+func (pk *DamgardJurikPublicKey) RequiredS(m *big.Int) int {
+	s := 1
+	for ns := new(big.Int).Set(pk.N); m.Cmp(ns) != -1; ns.Mul(ns, pk.N) {
+		s++
+	}
+	return s
+}
+
+// EncryptBlock encrypts a message bigger than N in a single cypher instead of
+// splitting it into several Paillier cyphers of at most N each. The message
+// must be in [0, N^S); the returned error states the S the message requires
+// otherwise. The message is recovered with `DamgardJurikPrivateKey.Decrypt`.
+func (pk *DamgardJurikPublicKey) EncryptBlock(m *big.Int, random RandReader) (*Cypher, error) {
+	if m.Sign() == -1 {
+		return nil, errors.New("message can not be negative")
+	}
+	if required := pk.RequiredS(m); required > pk.S {
+		return nil, fmt.Errorf(
+			"message does not fit in the plaintext space [0, N^%v), it requires S = %v",
+			pk.S,
+			required,
+		)
+	}
+
+	return pk.Encrypt(m, random)
+}
+
+// Add takes an arbitrary number of cyphertexts and returns one that encodes
+// their sum modulo N^S.
+//
+// D( (E(m1) * E(m2) mod N^(S+1)) ) = m1 + m2 mod N^S
+func (pk *DamgardJurikPublicKey) Add(cypher ...*Cypher) *Cypher {
+	nsPlusOne := pk.GetNSPlusOne()
+	accumulator := big.NewInt(1)
+
+	for _, c := range cypher {
+		accumulator = new(big.Int).Mod(
+			new(big.Int).Mul(accumulator, c.C),
+			nsPlusOne,
+		)
+	}
+
+	return &Cypher{
+		C: accumulator,
+	}
+}
+
+// Neg returns a cypher encoding the additive inverse of the plaintext of
+// `cypher` modulo N^S. It is the multiplicative inverse of `cypher` modulo
+// N^(S+1), so together with `Add` it allows subtraction at any level S:
+//
+// D( E(m)^-1 mod N^(S+1) ) = -m mod N^S
+//
+// An error is returned if `cypher` is not invertible modulo N^(S+1), which
+// never happens for cyphers produced by `Encrypt`.
+func (pk *DamgardJurikPublicKey) Neg(cypher *Cypher) (*Cypher, error) {
+	inverse, err := mustInverse(cypher.C, pk.GetNSPlusOne())
+	if err != nil {
+		return nil, err
+	}
+	return &Cypher{C: inverse}, nil
+}
+
+// Private key for the Damgård–Jurik generalization of the Paillier scheme.
+type DamgardJurikPrivateKey struct {
+	DamgardJurikPublicKey
+	Lambda *big.Int
+}
+
+// CreateDamgardJurikPrivateKey generates a Damgård–Jurik private key with
+// the plaintext space Z_{N^s} from two large prime numbers of equal length.
+// Just like in `CreatePrivateKey`, Euler's totient function is used for
+// Lambda.
+func CreateDamgardJurikPrivateKey(p, q *big.Int, s int) (*DamgardJurikPrivateKey, error) {
+	if s < 1 {
+		return nil, errors.New("s must be at least 1")
+	}
+
+	return &DamgardJurikPrivateKey{
+		DamgardJurikPublicKey: DamgardJurikPublicKey{
+			N: new(big.Int).Mul(p, q),
+			S: s,
+		},
+		Lambda: computePhi(p, q),
+	}, nil
+}
+
+// Decodes ciphertext into a plaintext message.
+//
+// c^Lambda mod N^(S+1) = (1 + N)^(m*Lambda mod N^S) so m*Lambda mod N^S is
+// recovered with `extractExponent` and multiplied by Lambda^-1 mod N^S.
+//
+// See [DJN 10], section 3.
+func (priv *DamgardJurikPrivateKey) Decrypt(cypher *Cypher) (*big.Int, error) {
+	ns := priv.GetNS()
+	lambdaInverse, err := mustInverse(priv.Lambda, ns)
+	if err != nil {
+		return nil, ErrLambdaNotInvertible
+	}
+
+	a := new(big.Int).Exp(cypher.C, priv.Lambda, priv.GetNSPlusOne())
+	i, err := priv.extractExponent(a)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).Mod(new(big.Int).Mul(i, lambdaInverse), ns), nil
+}
+
+// Lift converts a cypher of the level `fromS`, that is computed modulo
+// N^(fromS+1), into a fresh cypher of the higher level `toS` encrypting the
+// same plaintext, so that it can be added to cyphers of that level with
+// `Add` of a key with S = `toS`.
+//
+// Lifting requires the private key. A level-s cypher
+// c = (1 + N)^m r^(N^s) mod N^(s+1) determines m only modulo N^s: any c'
+// such that c' = c mod N^(s+1) is a level-t cypher of m + kN^s for some k
+// which is unknown without decryption. Hence the cypher is decrypted at level `fromS`
+// and the plaintext, which is smaller than N^fromS < N^toS, is encrypted
+// again at level `toS`. The other direction needs no key: c mod N^(s'+1) is
+// a level-s' cypher of m mod N^s' for any s' < s.
+func (priv *DamgardJurikPrivateKey) Lift(cypher *Cypher, fromS, toS int, random RandReader) (*Cypher, error) {
+	if fromS < 1 || toS <= fromS {
+		return nil, fmt.Errorf(
+			"can not lift a cypher from level %v to level %v",
+			fromS,
+			toS,
+		)
+	}
+
+	from := &DamgardJurikPrivateKey{
+		DamgardJurikPublicKey: DamgardJurikPublicKey{N: priv.N, S: fromS},
+		Lambda:                priv.Lambda,
+	}
+	m, err := from.Decrypt(cypher)
+	if err != nil {
+		return nil, err
+	}
+
+	to := &DamgardJurikPublicKey{N: priv.N, S: toS}
+	return to.Encrypt(m, random)
+}
+
+// Computes i from a = (1 + N)^i mod N^(S+1) with the algorithm from
+// [DJN 10], section 3, "A Generalization of Paillier's Scheme". The value of
+// i is found modulo N, N^2, ... N^S step by step.
+//
+// An error is returned if k! for some k <= S is not invertible modulo N^S,
+// which happens only for S not smaller than the prime factors of N.
+func (priv *DamgardJurikPrivateKey) extractExponent(a *big.Int) (*big.Int, error) {
+	n := priv.N
+	i := big.NewInt(0)
+	for j := 1; j <= priv.S; j++ {
+		nj := new(big.Int).Exp(n, big.NewInt(int64(j)), nil)
+		njPlusOne := new(big.Int).Mul(nj, n)
+
+		t1 := L(new(big.Int).Mod(a, njPlusOne), n)
+		t2 := new(big.Int).Set(i)
+		for k := 2; k <= j; k++ {
+			i = new(big.Int).Sub(i, ONE)
+			t2 = new(big.Int).Mod(new(big.Int).Mul(t2, i), nj)
+
+			// t1 = t1 - (t2 * N^(k-1)) / k! mod N^j
+			nkMinusOne := new(big.Int).Exp(n, big.NewInt(int64(k-1)), nil)
+			kFactorialInverse, err := mustInverse(Factorial(k), nj)
+			if err != nil {
+				return nil, err
+			}
+			tmp := new(big.Int).Mul(t2, nkMinusOne)
+			tmp = new(big.Int).Mul(tmp, kFactorialInverse)
+			t1 = new(big.Int).Mod(new(big.Int).Sub(t1, tmp), nj)
+		}
+		i = t1
+	}
+	return i, nil
+}
@@ -0,0 +1,260 @@
+package paillier
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+func TestCreateDamgardJurikPrivateKey(t *testing.T) {
+	privateKey, err := CreateDamgardJurikPrivateKey(big.NewInt(463), big.NewInt(631), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if privateKey.N.Cmp(big.NewInt(292153)) != 0 {
+		t.Errorf("Unexpected N value [%v]", privateKey.N)
+	}
+	if privateKey.GetNS().Cmp(big.NewInt(292153*292153)) != 0 {
+		t.Errorf("Unexpected N^S value [%v]", privateKey.GetNS())
+	}
+
+	if _, err := CreateDamgardJurikPrivateKey(big.NewInt(463), big.NewInt(631), 0); err == nil {
+		t.Error("expected an error for s = 0")
+	}
+}
+
+func TestDamgardJurikEncryptDecrypt(t *testing.T) {
+	for s := 1; s <= 4; s++ {
+		privateKey, err := CreateDamgardJurikPrivateKey(big.NewInt(463), big.NewInt(631), s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ns := privateKey.GetNS()
+
+		for _, m := range []*big.Int{
+			big.NewInt(0),
+			big.NewInt(1),
+			big.NewInt(292152),
+			new(big.Int).Sub(ns, big.NewInt(1)),
+			new(big.Int).Div(ns, big.NewInt(3)),
+		} {
+			cypher, err := privateKey.Encrypt(m, rand.Reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+			decrypted, err := privateKey.Decrypt(cypher)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if decrypted.Cmp(m) != 0 {
+				t.Errorf(
+					"Unexpected decryption for s = %v\nExpected: %v\nActual: %v",
+					s,
+					m,
+					decrypted,
+				)
+			}
+		}
+
+		if _, err := privateKey.Encrypt(ns, rand.Reader); err == nil {
+			t.Errorf("expected an error for N^S plaintext for s = %v", s)
+		}
+	}
+}
+
+func TestDamgardJurikAdd(t *testing.T) {
+	privateKey, err := CreateDamgardJurikPrivateKey(big.NewInt(463), big.NewInt(631), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// both plaintexts are bigger than N
+	m1 := big.NewInt(10000000000)
+	m2 := big.NewInt(20000000000)
+
+	cypher1, err := privateKey.Encrypt(m1, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cypher2, err := privateKey.Encrypt(m2, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sum, err := privateKey.Decrypt(privateKey.Add(cypher1, cypher2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sum.Cmp(big.NewInt(30000000000)) != 0 {
+		t.Errorf("Unexpected decrypted value [%v]", sum)
+	}
+}
+
+func TestDamgardJurikNeg(t *testing.T) {
+	privateKey, err := CreateDamgardJurikPrivateKey(big.NewInt(463), big.NewInt(631), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// plaintext bigger than N
+	cypher, err := privateKey.Encrypt(big.NewInt(10000000000), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	negated, err := privateKey.Neg(cypher)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sum, err := privateKey.Decrypt(privateKey.Add(cypher, negated))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sum.Sign() != 0 {
+		t.Errorf("Unexpected decrypted value [%v]", sum)
+	}
+
+	m, err := privateKey.Decrypt(negated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := new(big.Int).Sub(privateKey.GetNS(), big.NewInt(10000000000))
+	if m.Cmp(expected) != 0 {
+		t.Errorf(
+			"Unexpected decrypted value\nExpected: %v\nActual: %v",
+			expected,
+			m,
+		)
+	}
+
+	if _, err := privateKey.Neg(&Cypher{C: big.NewInt(463)}); err == nil {
+		t.Error("expected an error for a cypher not invertible modulo N^(S+1)")
+	}
+}
+
+func TestDamgardJurikRequiredS(t *testing.T) {
+	publicKey := &DamgardJurikPublicKey{N: big.NewInt(143), S: 2}
+
+	var tests = map[string]struct {
+		m         *big.Int
+		expectedS int
+	}{
+		"zero": {
+			m:         big.NewInt(0),
+			expectedS: 1,
+		},
+		"N - 1": {
+			m:         big.NewInt(142),
+			expectedS: 1,
+		},
+		"N": {
+			m:         big.NewInt(143),
+			expectedS: 2,
+		},
+		"N^2 - 1": {
+			m:         big.NewInt(143*143 - 1),
+			expectedS: 2,
+		},
+		"N^2": {
+			m:         big.NewInt(143 * 143),
+			expectedS: 3,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			if s := publicKey.RequiredS(test.m); s != test.expectedS {
+				t.Errorf(
+					"Unexpected S\nExpected: %v\nActual: %v",
+					test.expectedS,
+					s,
+				)
+			}
+		})
+	}
+}
+
+func TestDamgardJurikEncryptBlock(t *testing.T) {
+	privateKey, err := CreateDamgardJurikPrivateKey(big.NewInt(463), big.NewInt(631), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// N < m < N^2
+	m := new(big.Int).Mul(privateKey.N, big.NewInt(123456))
+	m.Add(m, big.NewInt(789))
+
+	cypher, err := privateKey.EncryptBlock(m, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decrypted, err := privateKey.Decrypt(cypher)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decrypted.Cmp(m) != 0 {
+		t.Errorf(
+			"Unexpected decryption\nExpected: %v\nActual: %v",
+			m,
+			decrypted,
+		)
+	}
+
+	if _, err := privateKey.EncryptBlock(privateKey.GetNS(), rand.Reader); err == nil {
+		t.Error("expected an error for a message not fitting in N^S")
+	}
+	if _, err := privateKey.EncryptBlock(big.NewInt(-1), rand.Reader); err == nil {
+		t.Error("expected an error for a negative message")
+	}
+}
+
+func TestDamgardJurikPlaintextBits(t *testing.T) {
+	// N = 221 is 8 bits long
+	publicKey := &DamgardJurikPublicKey{N: big.NewInt(221), S: 3}
+	if bits := publicKey.PlaintextBits(); bits != 21 {
+		t.Errorf("Unexpected plaintext bits [%v]", bits)
+	}
+}
+
+func TestDamgardJurikLift(t *testing.T) {
+	level1, err := CreateDamgardJurikPrivateKey(big.NewInt(463), big.NewInt(631), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	level2, err := CreateDamgardJurikPrivateKey(big.NewInt(463), big.NewInt(631), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cypher1, err := level1.Encrypt(big.NewInt(292000), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// bigger than N
+	cypher2, err := level2.Encrypt(big.NewInt(10000000000), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lifted, err := level2.Lift(cypher1, 1, 2, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sum, err := level2.Decrypt(level2.Add(lifted, cypher2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sum.Cmp(big.NewInt(10000292000)) != 0 {
+		t.Errorf(
+			"Unexpected decryption\nExpected: %v\nActual: %v",
+			10000292000,
+			sum,
+		)
+	}
+
+	if _, err := level2.Lift(cypher1, 2, 1, rand.Reader); err == nil {
+		t.Error("expected an error for lifting to a lower level")
+	}
+}
@@ -0,0 +1,90 @@
+package paillier
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// DecryptionSession collects partial decryptions of a single cypher text as
+// they arrive from the decryption servers. Every share is verified once, when
+// added, and shares coming from the same server are rejected, so the message
+// can be combined as soon as `Threshold` valid shares have been collected
+// without buffering and re-verifying all of them each time.
+//
+// A session is not safe for concurrent use.
+type DecryptionSession struct {
+	Key *ThresholdPublicKey // the threshold key the shares are verified with
+	C   *big.Int            // the cypher text being decrypted
+
+	shares []*PartialDecryption
+	ids    map[int]bool
+}
+
+// GetDecryptionSession starts a session collecting the partial decryptions of
+// the cypher text `c`. An error is returned if the threshold of the key is
+// lower than one.
+func (tk *ThresholdPublicKey) GetDecryptionSession(c *big.Int) (*DecryptionSession, error) {
+	if tk.Threshold < 1 {
+		return nil, errors.New("Threshold must be at least 1")
+	}
+	return &DecryptionSession{
+		Key:    tk,
+		C:      c,
+		shares: make([]*PartialDecryption, 0, tk.Threshold),
+		ids:    make(map[int]bool),
+	}, nil
+}
+
+// AddShare verifies the zero-knowledge proof of the share against the
+// session key and adds the share to the session. An error is returned if the
+// share is nil or incomplete, if it is for a different cypher text, if the
+// proof is not valid or if a share from the same decryption server has already
+// been added.
+func (ds *DecryptionSession) AddShare(share *PartialDecryptionZKP) error {
+	// Shares come from other servers and must not crash the session.
+	if share == nil {
+		return errors.New("share is nil")
+	}
+	if share.Key == nil || !share.isComplete() {
+		return fmt.Errorf("share from server %v is incomplete", share.Id)
+	}
+	if share.C.Cmp(ds.C) != 0 {
+		return errors.New("share has been produced for a different cypher text")
+	}
+	if ds.ids[share.Id] {
+		return fmt.Errorf("share from server %v has already been added", share.Id)
+	}
+
+	// The proof is checked against the session key, not the one carried by
+	// the share which can not be trusted.
+	verified := *share
+	verified.Key = ds.Key
+	if !verified.Verify() {
+		return fmt.Errorf("share from server %v failed verification", share.Id)
+	}
+
+	// A copy is stored so that the caller can't replace the verified
+	// decryption with an unverified one after the fact.
+	ds.ids[share.Id] = true
+	ds.shares = append(ds.shares, &PartialDecryption{
+		Id:         share.Id,
+		Decryption: new(big.Int).Set(share.Decryption),
+	})
+	return nil
+}
+
+// TryCombine combines the shares collected so far. The returned boolean is
+// false, with no error, as long as fewer than `Threshold` shares have been
+// added.
+func (ds *DecryptionSession) TryCombine() (*big.Int, bool, error) {
+	if len(ds.shares) < ds.Key.Threshold {
+		return nil, false, nil
+	}
+
+	message, err := ds.Key.CombinePartialDecryptions(ds.shares)
+	if err != nil {
+		return nil, false, err
+	}
+	return message, true, nil
+}
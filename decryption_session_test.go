@@ -0,0 +1,238 @@
+package paillier
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+func TestDecryptionSession(t *testing.T) {
+	tkh, err := GetThresholdKeyGenerator(32, 5, 3, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tpks, err := tkh.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	message := b(100)
+	c, err := tpks[0].Encrypt(message, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	session, err := tpks[0].getThresholdKey().GetDecryptionSession(c.C)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, ok, err := session.TryCombine(); ok || err != nil {
+			t.Fatalf("combination should not be possible with %v shares", i)
+		}
+
+		share, err := tpks[i].DecryptAndProduceZKP(c.C, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := session.AddShare(share); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	decrypted, ok, err := session.TryCombine()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Fatal("combination should be possible at the threshold")
+	}
+	if decrypted.Cmp(message) != 0 {
+		t.Errorf(
+			"Unexpected decryption\nExpected: %v\nActual: %v",
+			message,
+			decrypted,
+		)
+	}
+}
+
+func TestDecryptionSessionCopiesShares(t *testing.T) {
+	tkh, err := GetThresholdKeyGenerator(32, 3, 2, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tpks, err := tkh.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	message := b(100)
+	c, err := tpks[0].Encrypt(message, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	session, err := tpks[0].getThresholdKey().GetDecryptionSession(c.C)
+	if err != nil {
+		t.Fatal(err)
+	}
+	shares := make([]*PartialDecryptionZKP, 2)
+	for i := range shares {
+		if shares[i], err = tpks[i].DecryptAndProduceZKP(c.C, rand.Reader); err != nil {
+			t.Fatal(err)
+		}
+		if err := session.AddShare(shares[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// tamper with the shares after they have been verified
+	shares[0].Decryption.SetInt64(1)
+	shares[1].Decryption = b(2)
+	shares[1].Id = 3
+
+	decrypted, ok, err := session.TryCombine()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Fatal("combination should be possible at the threshold")
+	}
+	if decrypted.Cmp(message) != 0 {
+		t.Errorf(
+			"Unexpected decryption\nExpected: %v\nActual: %v",
+			message,
+			decrypted,
+		)
+	}
+}
+
+func TestDecryptionSessionRejectsShares(t *testing.T) {
+	tkh, err := GetThresholdKeyGenerator(32, 3, 2, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tpks, err := tkh.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := tpks[0].Encrypt(b(100), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherC, err := tpks[0].Encrypt(b(101), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	session, err := tpks[0].getThresholdKey().GetDecryptionSession(c.C)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	share, err := tpks[0].DecryptAndProduceZKP(c.C, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := session.AddShare(share); err != nil {
+		t.Fatal(err)
+	}
+	if err := session.AddShare(share); err == nil {
+		t.Error("expected an error for a duplicated share")
+	}
+
+	otherShare, err := tpks[1].DecryptAndProduceZKP(otherC.C, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := session.AddShare(otherShare); err == nil {
+		t.Error("expected an error for a share of another cypher text")
+	}
+
+	forged, err := tpks[1].DecryptAndProduceZKP(c.C, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged.E = new(big.Int).Add(forged.E, ONE)
+	if err := session.AddShare(forged); err == nil {
+		t.Error("expected an error for a share with an invalid proof")
+	}
+
+	if _, ok, _ := session.TryCombine(); ok {
+		t.Error("rejected shares should not count towards the threshold")
+	}
+}
+
+func TestDecryptionSessionRejectsIncompleteShares(t *testing.T) {
+	tkh, err := GetThresholdKeyGenerator(32, 3, 2, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tpks, err := tkh.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := tpks[0].Encrypt(b(100), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	share, err := tpks[0].DecryptAndProduceZKP(c.C, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var tests = map[string]struct {
+		tamper func(*PartialDecryptionZKP)
+	}{
+		"nil E": {
+			tamper: func(share *PartialDecryptionZKP) { share.E = nil },
+		},
+		"nil Z": {
+			tamper: func(share *PartialDecryptionZKP) { share.Z = nil },
+		},
+		"nil Decryption": {
+			tamper: func(share *PartialDecryptionZKP) { share.Decryption = nil },
+		},
+		"nil Key": {
+			tamper: func(share *PartialDecryptionZKP) { share.Key = nil },
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			session, err := tpks[0].getThresholdKey().GetDecryptionSession(c.C)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			incomplete := *share
+			test.tamper(&incomplete)
+			if err := session.AddShare(&incomplete); err == nil {
+				t.Error("expected an error for an incomplete share")
+			}
+		})
+	}
+
+	session, err := tpks[0].getThresholdKey().GetDecryptionSession(c.C)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := session.AddShare(nil); err == nil {
+		t.Error("expected an error for a nil share")
+	}
+}
+
+func TestGetDecryptionSessionRejectsThresholdBelowOne(t *testing.T) {
+	for _, threshold := range []int{0, -1} {
+		tk := &ThresholdPublicKey{
+			PublicKey:                      PublicKey{N: b(15)},
+			TotalNumberOfDecryptionServers: 3,
+			Threshold:                      threshold,
+		}
+		if _, err := tk.GetDecryptionSession(b(4)); err == nil {
+			t.Errorf("expected an error for threshold %v", threshold)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package paillier
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// Encoder maps signed integers and fixed-point numbers to the plaintext space
+// [0, N) of a public key, so that they can be encrypted and added or
+// multiplied by integer scalars homomorphically. `Decoder` reverses the
+// mapping after decryption.
+//
+// A signed integer `x` in [-(N-1)/2, (N-1)/2] is encoded as `x mod N`: the
+// non-negative values are kept as they are and the negative ones are mapped
+// to the upper half of the plaintext space. The homomorphic operations work
+// modulo N, so the result is decoded correctly as long as it stays in the
+// range, no overflow can be detected.
+//
+// A fixed-point number `x` is encoded as the signed integer
+// `round(x * 2^Precision)`. Sums of fixed-point numbers encoded with the same
+// `Precision` keep it, products by integer scalars as well.
+type Encoder struct {
+	Precision uint
+
+	n   *big.Int
+	max *big.Int // max = (N-1)/2
+}
+
+// GetEncoder constructs the Encoder for the plaintext space of `publicKey`
+// with `precision` fractional bits of fixed-point numbers.
+func GetEncoder(publicKey *PublicKey, precision uint) *Encoder {
+	return &Encoder{
+		Precision: precision,
+		n:         new(big.Int).Set(publicKey.N),
+		max:       new(big.Int).Rsh(minusOne(publicKey.N), 1),
+	}
+}
+
+// Encode maps the signed integer `x` to the plaintext space. An error is
+// returned if `x` is out of [-(N-1)/2, (N-1)/2].
+func (e *Encoder) Encode(x *big.Int) (*big.Int, error) {
+	if new(big.Int).Abs(x).Cmp(e.max) > 0 {
+		return nil, fmt.Errorf(
+			"%v is out of allowed signed range [-%v, %v]",
+			x,
+			e.max,
+			e.max,
+		)
+	}
+	return new(big.Int).Mod(x, e.n), nil
+}
+
+// EncodeFixed maps the fixed-point number `x` to the plaintext space, see
+// `Encoder`. `x` is rounded to the nearest multiple of 2^-Precision, halves
+// away from zero. An error is returned if the scaled value is out of range.
+func (e *Encoder) EncodeFixed(x *big.Float) (*big.Int, error) {
+	scaled := new(big.Float).SetMantExp(x, int(e.Precision))
+	half := big.NewFloat(0.5)
+	if scaled.Sign() < 0 {
+		half.Neg(half)
+	}
+	rounded, _ := scaled.Add(scaled, half).Int(nil)
+	return e.Encode(rounded)
+}
+
+// Decoder returns the `Decoder` reversing the mapping of this Encoder.
+func (e *Encoder) Decoder() *Decoder {
+	return &Decoder{
+		Precision: e.Precision,
+		n:         e.n,
+		max:       e.max,
+	}
+}
+
+// Decoder maps plaintexts produced by `Encoder`, possibly after homomorphic
+// operations on their cyphers, back to signed integers and fixed-point
+// numbers. It must be constructed for the same key and with the same
+// `Precision` as the Encoder.
+type Decoder struct {
+	Precision uint
+
+	n   *big.Int
+	max *big.Int // max = (N-1)/2
+}
+
+// GetDecoder constructs the Decoder for the plaintext space of `publicKey`
+// with `precision` fractional bits of fixed-point numbers.
+func GetDecoder(publicKey *PublicKey, precision uint) *Decoder {
+	return GetEncoder(publicKey, precision).Decoder()
+}
+
+// Decode maps the plaintext `m` from [0, N) back to the signed integer in
+// [-(N-1)/2, (N-1)/2] it encodes.
+func (d *Decoder) Decode(m *big.Int) *big.Int {
+	x := new(big.Int).Mod(m, d.n)
+	if x.Cmp(d.max) > 0 {
+		x.Sub(x, d.n)
+	}
+	return x
+}
+
+// DecodeFixed maps the plaintext `m` back to the fixed-point number it
+// encodes, that is the signed integer it encodes divided by 2^Precision.
+// The division is exact.
+func (d *Decoder) DecodeFixed(m *big.Int) *big.Float {
+	x := new(big.Float).SetInt(d.Decode(m))
+	return x.SetMantExp(x, -int(d.Precision))
+}
+
+// DecryptDecoded decrypts `cypher` and maps the plaintext back to the signed
+// integer it encodes with `decoder`.
+func (priv *PrivateKey) DecryptDecoded(cypher *Cypher, decoder *Decoder) *big.Int {
+	return decoder.Decode(priv.Decrypt(cypher))
+}
@@ -0,0 +1,157 @@
+package paillier
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+func TestEncoderSignedRoundTrip(t *testing.T) {
+	// N = 17 * 13 = 221, signed range is [-110, 110]
+	privateKey := CreatePrivateKey(big.NewInt(17), big.NewInt(13))
+	encoder := GetEncoder(&privateKey.PublicKey, 0)
+	decoder := encoder.Decoder()
+
+	var tests = map[string]struct {
+		value           int64
+		expectedEncoded int64
+	}{
+		"zero": {
+			value:           0,
+			expectedEncoded: 0,
+		},
+		"positive": {
+			value:           7,
+			expectedEncoded: 7,
+		},
+		"negative": {
+			value:           -5,
+			expectedEncoded: 216,
+		},
+		"max": {
+			value:           110,
+			expectedEncoded: 110,
+		},
+		"min": {
+			value:           -110,
+			expectedEncoded: 111,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			encoded, err := encoder.Encode(big.NewInt(test.value))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if encoded.Int64() != test.expectedEncoded {
+				t.Errorf(
+					"Unexpected encoded value\nExpected: %v\nActual: %v",
+					test.expectedEncoded,
+					encoded,
+				)
+			}
+
+			cypher, err := privateKey.Encrypt(encoded, rand.Reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+			decoded := privateKey.DecryptDecoded(cypher, decoder)
+			if decoded.Int64() != test.value {
+				t.Errorf(
+					"Unexpected decoded value\nExpected: %v\nActual: %v",
+					test.value,
+					decoded,
+				)
+			}
+		})
+	}
+
+	for _, value := range []int64{111, -111} {
+		if _, err := encoder.Encode(big.NewInt(value)); err == nil {
+			t.Errorf("expected an error for out of range value %v", value)
+		}
+	}
+}
+
+func TestEncoderHomomorphicSignedSum(t *testing.T) {
+	privateKey := CreatePrivateKey(big.NewInt(17), big.NewInt(13))
+	encoder := GetEncoder(&privateKey.PublicKey, 0)
+
+	cyphers := make([]*Cypher, 0)
+	for _, value := range []int64{-30, 12, -4} {
+		encoded, err := encoder.Encode(big.NewInt(value))
+		if err != nil {
+			t.Fatal(err)
+		}
+		cypher, err := privateKey.Encrypt(encoded, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cyphers = append(cyphers, cypher)
+	}
+
+	// -30 + 12 - 4 = -22
+	sum := privateKey.DecryptDecoded(privateKey.Add(cyphers...), encoder.Decoder())
+	if sum.Int64() != -22 {
+		t.Errorf("Unexpected decrypted value [%v]", sum)
+	}
+}
+
+func TestEncoderFixedRoundTrip(t *testing.T) {
+	privateKey := CreatePrivateKey(big.NewInt(463), big.NewInt(631))
+	encoder := GetEncoder(&privateKey.PublicKey, 8)
+	decoder := GetDecoder(&privateKey.PublicKey, 8)
+
+	var tests = map[string]struct {
+		value         float64
+		expectedValue float64
+	}{
+		"positive fraction": {
+			value:         3.5,
+			expectedValue: 3.5,
+		},
+		"negative fraction": {
+			value:         -1.25,
+			expectedValue: -1.25,
+		},
+		"rounded up": {
+			value:         0.0039,
+			expectedValue: 0.00390625, // 2^-8
+		},
+		"negative rounded away from zero": {
+			value:         -0.0039,
+			expectedValue: -0.00390625,
+		},
+		"rounded down": {
+			value:         0.001,
+			expectedValue: 0,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			encoded, err := encoder.EncodeFixed(big.NewFloat(test.value))
+			if err != nil {
+				t.Fatal(err)
+			}
+			cypher, err := privateKey.Encrypt(encoded, rand.Reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			decoded, _ := decoder.DecodeFixed(privateKey.Decrypt(cypher)).Float64()
+			if decoded != test.expectedValue {
+				t.Errorf(
+					"Unexpected decoded value\nExpected: %v\nActual: %v",
+					test.expectedValue,
+					decoded,
+				)
+			}
+		})
+	}
+
+	if _, err := encoder.EncodeFixed(big.NewFloat(1e10)); err == nil {
+		t.Error("expected an error for out of range value")
+	}
+}
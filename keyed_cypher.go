@@ -0,0 +1,74 @@
+package paillier
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"math/big"
+)
+
+// ErrKeyMismatch is returned by the operations on `KeyedCypher` when one of
+// the operands has been produced with another public key.
+var ErrKeyMismatch = errors.New("cypher has been produced with another public key")
+
+// Fingerprint returns the SHA-256 hash of the public key modulus `N`. Two
+// public keys have the same fingerprint if and only if they are equal.
+func (pk *PublicKey) Fingerprint() []byte {
+	hash := sha256.Sum256(pk.N.Bytes())
+	return hash[:]
+}
+
+// KeyedCypher is a cypher recording the fingerprint of the public key which
+// produced it.
+//
+// A `Cypher` carries only `C`, so combining cyphers from different keys with
+// `PublicKey.Add` or `PublicKey.Mul` silently produces garbage. The operations
+// on `KeyedCypher`, `PublicKey.AddKeyed` and `PublicKey.MulKeyed`, check the
+// fingerprint of every operand and return `ErrKeyMismatch` instead. Plain
+// cyphers keep working unchecked.
+type KeyedCypher struct {
+	Cypher      *Cypher
+	Fingerprint []byte
+}
+
+// Keyed tags `cypher` with the fingerprint of the key. The cypher is assumed
+// to have been produced with this key.
+func (pk *PublicKey) Keyed(cypher *Cypher) *KeyedCypher {
+	return &KeyedCypher{
+		Cypher:      cypher,
+		Fingerprint: pk.Fingerprint(),
+	}
+}
+
+// EncryptKeyed encrypts the plaintext like `Encrypt` and tags the resulting
+// cypher with the fingerprint of the key.
+func (pk *PublicKey) EncryptKeyed(m *big.Int, random RandReader) (*KeyedCypher, error) {
+	cypher, err := pk.Encrypt(m, random)
+	if err != nil {
+		return nil, err
+	}
+	return pk.Keyed(cypher), nil
+}
+
+// AddKeyed works like `Add` but first checks that all the cyphers have been
+// produced with this key.
+func (pk *PublicKey) AddKeyed(cypher ...*KeyedCypher) (*KeyedCypher, error) {
+	fingerprint := pk.Fingerprint()
+	cyphers := make([]*Cypher, len(cypher))
+	for i, c := range cypher {
+		if !bytes.Equal(c.Fingerprint, fingerprint) {
+			return nil, ErrKeyMismatch
+		}
+		cyphers[i] = c.Cypher
+	}
+	return pk.Keyed(pk.Add(cyphers...)), nil
+}
+
+// MulKeyed works like `Mul` but first checks that the cypher has been
+// produced with this key.
+func (pk *PublicKey) MulKeyed(cypher *KeyedCypher, scalar *big.Int) (*KeyedCypher, error) {
+	if !bytes.Equal(cypher.Fingerprint, pk.Fingerprint()) {
+		return nil, ErrKeyMismatch
+	}
+	return pk.Keyed(pk.Mul(cypher.Cypher, scalar)), nil
+}
@@ -0,0 +1,56 @@
+package paillier
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+func TestKeyedCypher(t *testing.T) {
+	privateKey := CreatePrivateKey(big.NewInt(463), big.NewInt(631))
+	otherKey := CreatePrivateKey(big.NewInt(467), big.NewInt(619))
+
+	cypher1, err := privateKey.EncryptKeyed(big.NewInt(12), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cypher2, err := privateKey.EncryptKeyed(big.NewInt(30), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sum, err := privateKey.AddKeyed(cypher1, cypher2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	product, err := privateKey.MulKeyed(sum, big.NewInt(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m := privateKey.Decrypt(product.Cypher); m.Cmp(big.NewInt(84)) != 0 {
+		t.Errorf(
+			"Unexpected decryption\nExpected: %v\nActual: %v",
+			84,
+			m,
+		)
+	}
+
+	foreign, err := otherKey.EncryptKeyed(big.NewInt(1), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := privateKey.AddKeyed(cypher1, foreign); err != ErrKeyMismatch {
+		t.Errorf(
+			"Unexpected error\nExpected: %v\nActual: %v",
+			ErrKeyMismatch,
+			err,
+		)
+	}
+	if _, err := privateKey.MulKeyed(foreign, big.NewInt(2)); err != ErrKeyMismatch {
+		t.Errorf(
+			"Unexpected error\nExpected: %v\nActual: %v",
+			ErrKeyMismatch,
+			err,
+		)
+	}
+}
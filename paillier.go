@@ -1,23 +1,118 @@
 package paillier
 
 import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"math/big"
+	"runtime"
+	"sync"
 )
 
+// ErrTrivialCypher is returned by `PrivateKey.DecryptChecked` for cyphertexts
+// which have been computed without any randomness.
+var ErrTrivialCypher = errors.New("cyphertext is trivially structured")
+
+// ErrLambdaNotInvertible is returned by `PrivateKey.DecryptChecked` when the
+// key has no `Mu` and `Lambda` is not invertible modulo N which means the key
+// is corrupted.
+var ErrLambdaNotInvertible = errors.New("Lambda is not invertible modulo N")
+
+// Public key of the Paillier scheme.
+//
+// The generator g is always N+1 and, unlike in some other implementations,
+// it can't be chosen: there is no `G` field and all the operations assume
+// g = N+1. The threshold scheme is secure only for this choice, see [DJN 10],
+// section 5.1, so a public key can always be safely used with threshold keys.
 type PublicKey struct {
 	N *big.Int
 }
 
+// Homomorphic is the minimal set of operations of an additively homomorphic
+// encryption scheme. Code depending on it, instead of on PublicKey directly,
+// can swap the backend, e.g. use a mock in tests.
+type Homomorphic interface {
+	Encrypt(m *big.Int, random RandReader) (*Cypher, error)
+	Add(cypher ...*Cypher) *Cypher
+	Mul(cypher *Cypher, scalar *big.Int) *Cypher
+	Sub(cypher1, cypher2 *Cypher) *Cypher
+}
+
+var _ Homomorphic = (*PublicKey)(nil)
+var _ Homomorphic = (*PrivateKey)(nil)
+
 func (pk *PublicKey) GetNSquare() *big.Int {
 	return new(big.Int).Mul(pk.N, pk.N)
 }
 
+// Equal compares the public key with `other` by value.
+func (pk *PublicKey) Equal(other *PublicKey) bool {
+	if other == nil {
+		return false
+	}
+	return pk.N.Cmp(other.N) == 0
+}
+
+// PlaintextSpace returns the bounds of the plaintext space of the key which
+// is [0, N). `min` is inclusive and `max` is exclusive.
+func (pk *PublicKey) PlaintextSpace() (min, max *big.Int) {
+	return big.NewInt(0), new(big.Int).Set(pk.N)
+}
+
+// InPlaintextSpace checks whether `m` belongs to the plaintext space [0, N).
+func (pk *PublicKey) InPlaintextSpace(m *big.Int) bool {
+	return m.Cmp(ZERO) != -1 && m.Cmp(pk.N) == -1 // 0 <= m < N  ?
+}
+
+// PlaintextBits returns the number of bits which can always be encoded in
+// a plaintext, that is `N.BitLen() - 1`. A plaintext must be smaller than N
+// and only the values up to 2^(N.BitLen()-1) - 1 are guaranteed to be, since
+// N itself has `N.BitLen()` bits.
+func (pk *PublicKey) PlaintextBits() int {
+	return pk.N.BitLen() - 1
+}
+
+func (pk *PublicKey) plaintextSpaceError(m *big.Int) error {
+	return fmt.Errorf("%v is out of allowed plaintext space [0, %v)", m, pk.N)
+}
+
+// TextPublicKey is a PublicKey implementing encoding.TextMarshaler and
+// encoding.TextUnmarshaler, so that the key can be carried by environment
+// variables, command-line flags (see flag.TextVar) or string fields of
+// configuration files. The key is encoded as the hexadecimal `N`.
+//
+// The interfaces are not implemented by PublicKey itself because the methods
+// would be promoted to PrivateKey and ThresholdPublicKey, which embed it, and
+// change their JSON encoding.
+type TextPublicKey PublicKey
+
+// MarshalText implements encoding.TextMarshaler.
+func (tpk *TextPublicKey) MarshalText() ([]byte, error) {
+	if tpk.N == nil {
+		return nil, errors.New("public key has no N")
+	}
+	return []byte(fmt.Sprintf("%x", tpk.N)), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (tpk *TextPublicKey) UnmarshalText(text []byte) error {
+	n, ok := new(big.Int).SetString(string(text), 16)
+	if !ok || n.Sign() != 1 {
+		return fmt.Errorf("%q is not a valid hexadecimal public key", text)
+	}
+	tpk.N = n
+	return nil
+}
+
 // EncryptWithR encrypts a plaintext into a cypher one with random `r` specified
 // in the argument. The plain text must be smaller that N and bigger than or
 // equal zero. `r` is the randomness used to encrypt the plaintext. `r` must be
-// a random element from a multiplicative group of integers modulo N.
+// a random element from a multiplicative group of integers modulo N, an error
+// is returned otherwise.
 //
 // If you don't need to use the specific `r`, you should use the `Encrypt`
 // function instead.
@@ -28,12 +123,14 @@ func (pk *PublicKey) GetNSquare() *big.Int {
 //
 // See [KL 08] construction 11.32, page 414.
 func (pk *PublicKey) EncryptWithR(m *big.Int, r *big.Int) (*Cypher, error) {
-	if m.Cmp(ZERO) == -1 || m.Cmp(pk.N) != -1 { // m < 0 || m >= N  ?
-		return nil, fmt.Errorf(
-			"%v is out of allowed plaintext space [0, %v)",
-			m,
-			pk.N,
-		)
+	if !pk.InPlaintextSpace(m) {
+		return nil, pk.plaintextSpaceError(m)
+	}
+	if r.Cmp(ONE) == -1 || r.Cmp(pk.N) != -1 { // r < 1 || r >= N  ?
+		return nil, fmt.Errorf("r is out of allowed range [1, %v)", pk.N)
+	}
+	if new(big.Int).GCD(nil, nil, r, pk.N).Cmp(ONE) != 0 {
+		return nil, errors.New("r is not invertible modulo N")
 	}
 
 	nSquare := pk.GetNSquare()
@@ -41,12 +138,118 @@ func (pk *PublicKey) EncryptWithR(m *big.Int, r *big.Int) (*Cypher, error) {
 	// g is _always_ equal n+1
 	// Threshold encryption is safe only for g=n+1 choice.
 	// See [DJN 10], section 5.1
-	g := new(big.Int).Add(pk.N, big.NewInt(1))
-	gm := new(big.Int).Exp(g, m, nSquare)
+	//
+	// Since g=n+1, from the binomial theorem we have
+	// g^m = (1 + n)^m = 1 + mn mod n^2
+	// which is much cheaper to compute than the modular exponentiation.
+	gm := new(big.Int).Add(ONE, new(big.Int).Mul(m, pk.N))
 	rn := new(big.Int).Exp(r, pk.N, nSquare)
 	return &Cypher{new(big.Int).Mod(new(big.Int).Mul(rn, gm), nSquare)}, nil
 }
 
+// EncryptVector encrypts `m` with the given `r` exactly like `EncryptWithR`.
+// It exists to make explicit that the cypher is fully determined by `m`, `r`
+// and N so it can be used to produce and check test vectors shared with other
+// Paillier implementations. The formula used is
+//
+//     E(m, r) = g^m * r^N mod N^2, with g = N + 1
+//
+// computed as (1 + m*N) * r^N mod N^2. The cypher C is the unique
+// representative in [0, N^2), serialized as a big-endian unsigned integer
+// without leading zeros by `C.Bytes()`. `r` must be in [1, N) and coprime
+// with N, no reduction modulo N is applied to it.
+func (pk *PublicKey) EncryptVector(m, r *big.Int) (*Cypher, error) {
+	return pk.EncryptWithR(m, r)
+}
+
+// EncryptionVector is a test vector of `EncryptVector`: plaintext `M`
+// encrypted with randomness `R` is expected to produce cypher `C`.
+type EncryptionVector struct {
+	M *big.Int
+	R *big.Int
+	C *big.Int
+}
+
+// CheckEncryptionVector encrypts the plaintext of `vector` with its
+// randomness and returns an error if the cypher differs from the expected
+// one.
+func (pk *PublicKey) CheckEncryptionVector(vector *EncryptionVector) error {
+	cypher, err := pk.EncryptVector(vector.M, vector.R)
+	if err != nil {
+		return err
+	}
+	if cypher.C.Cmp(vector.C) != 0 {
+		return fmt.Errorf(
+			"encryption vector mismatch, expected cypher %x but got %x",
+			vector.C,
+			cypher.C,
+		)
+	}
+	return nil
+}
+
+// VerifyOpening checks that `cypher` is the encryption of `m` with the
+// randomness `r`, that is, `cypher` is equal E(m, r). A party may reveal
+// (m, r) to prove a cypher has been honestly formed, e.g. in dispute
+// resolution. An error is returned if `m` or `r` is out of the range accepted
+// by `EncryptWithR`.
+func (pk *PublicKey) VerifyOpening(cypher *Cypher, m, r *big.Int) (bool, error) {
+	expected, err := pk.EncryptWithR(m, r)
+	if err != nil {
+		return false, err
+	}
+	return expected.C.Cmp(cypher.C) == 0, nil
+}
+
+// CommitAndEncrypt encrypts `m` and returns, together with the cypher and the
+// randomness `r` used, a SHA-256 commitment to `m`. It supports commit-reveal
+// schemes: the commitment is published first and, once `m` is revealed, it is
+// checked with `VerifyCommitment` while `VerifyOpening` checks the cypher
+// against the revealed (m, r).
+//
+// The commitment is bound to the public key but contains no randomness of
+// its own so it hides `m` only if `m` can't be guessed; small plaintext
+// spaces, e.g. yes/no votes, can be brute-forced from the commitment.
+func (pk *PublicKey) CommitAndEncrypt(m *big.Int, random RandReader) (
+	commitment []byte,
+	cypher *Cypher,
+	r *big.Int,
+	err error,
+) {
+	r, err = GetRandomNumberInMultiplicativeGroup(pk.N, random)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	cypher, err = pk.EncryptWithR(m, r)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return pk.commitment(m), cypher, r, nil
+}
+
+// VerifyCommitment checks that `commitment` has been produced by
+// `CommitAndEncrypt` for the plaintext `m` under this public key.
+func (pk *PublicKey) VerifyCommitment(commitment []byte, m *big.Int) bool {
+	if !pk.InPlaintextSpace(m) {
+		return false
+	}
+	return bytes.Equal(commitment, pk.commitment(m))
+}
+
+// commitment returns SHA-256(N || m) with `m` padded to the byte length of N
+// so that the encoding is unambiguous.
+func (pk *PublicKey) commitment(m *big.Int) []byte {
+	nBytes := pk.N.Bytes()
+	mBytes := m.Bytes()
+	padded := make([]byte, len(nBytes))
+	copy(padded[len(padded)-len(mBytes):], mBytes)
+
+	hash := sha256.New()
+	hash.Write(nBytes)
+	hash.Write(padded)
+	return hash.Sum(nil)
+}
+
 // Encrypt a plaintext into a cypher one. The plain text must be smaller that
 // N and bigger than or equal zero. random is usually rand.Reader from the
 // package crypto/rand.
@@ -56,8 +259,8 @@ func (pk *PublicKey) EncryptWithR(m *big.Int, r *big.Int) (*Cypher, error) {
 //
 // See [KL 08] construction 11.32, page 414.
 //
-// Returns an error if an error has be returned by io.Reader.
-func (pk *PublicKey) Encrypt(m *big.Int, random io.Reader) (*Cypher, error) {
+// Returns an error if an error has be returned by RandReader.
+func (pk *PublicKey) Encrypt(m *big.Int, random RandReader) (*Cypher, error) {
 	r, err := GetRandomNumberInMultiplicativeGroup(pk.N, random)
 	if err != nil {
 		return nil, err
@@ -66,6 +269,31 @@ func (pk *PublicKey) Encrypt(m *big.Int, random io.Reader) (*Cypher, error) {
 	return pk.EncryptWithR(m, r)
 }
 
+// EncryptNonZero works like `Encrypt` but returns an error if `m` is 0. It is
+// a guardrail for protocols in which an encrypted 0 is a degenerate value the
+// caller must never produce.
+func (pk *PublicKey) EncryptNonZero(m *big.Int, random RandReader) (*Cypher, error) {
+	if m.Sign() == 0 {
+		return nil, errors.New("plaintext must not be zero")
+	}
+	return pk.Encrypt(m, random)
+}
+
+// EncryptZero returns the encryption of zero together with the randomness `r`
+// used. It is commonly used for rerandomization and masking.
+//
+// E(0, r) = r^N mod N^2
+//
+// Returns an error if an error has be returned by RandReader.
+func (pk *PublicKey) EncryptZero(random RandReader) (*Cypher, *big.Int, error) {
+	r, err := GetRandomNumberInMultiplicativeGroup(pk.N, random)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &Cypher{new(big.Int).Exp(r, pk.N, pk.GetNSquare())}, r, nil
+}
+
 // Add takes an arbitrary number of cyphertexts and returns one that encodes
 // their sum.
 //
@@ -74,13 +302,26 @@ func (pk *PublicKey) Encrypt(m *big.Int, random io.Reader) (*Cypher, error) {
 // plaintexts:
 //
 // D( (E(m1) * E(m2) mod n^2) ) = m1 + m2 mod n
+//
+// Called without any cyphertext, Add returns a cypher with C = 1 which is
+// a valid, non-randomized, encryption of 0, the neutral element of addition.
+// Called with a single cyphertext, Add returns its copy.
+//
+// The returned cypher never shares memory with the arguments.
 func (pk *PublicKey) Add(cypher ...*Cypher) *Cypher {
-	accumulator := big.NewInt(1)
+	switch len(cypher) {
+	case 0:
+		return &Cypher{C: big.NewInt(1)}
+	case 1:
+		return &Cypher{C: new(big.Int).Set(cypher[0].C)}
+	}
 
-	for _, c := range cypher {
+	nSquare := pk.GetNSquare()
+	accumulator := new(big.Int).Set(cypher[0].C)
+	for _, c := range cypher[1:] {
 		accumulator = new(big.Int).Mod(
 			new(big.Int).Mul(accumulator, c.C),
-			pk.GetNSquare(),
+			nSquare,
 		)
 	}
 
@@ -89,6 +330,33 @@ func (pk *PublicKey) Add(cypher ...*Cypher) *Cypher {
 	}
 }
 
+// AddInto works like `Add` but stores the sum in `dst`, reusing the memory
+// of `dst.C`, instead of allocating a new cypher. It is meant for tight loops
+// aggregating many cyphers where the allocations made by `Add` dominate.
+//
+// `dst` is mutated. It may be the first of `cypher`, so that
+// `AddInto(sum, sum, c)` adds `c` to `sum` in place, but must not be any
+// other of them.
+func (pk *PublicKey) AddInto(dst *Cypher, cypher ...*Cypher) {
+	if dst.C == nil {
+		dst.C = new(big.Int)
+	}
+	if len(cypher) == 0 {
+		dst.C.SetInt64(1)
+		return
+	}
+
+	// Cyphers are positive so the remainder of QuoRem is the modulo. Unlike
+	// Mod, QuoRem reuses the quotient storage between iterations.
+	nSquare := pk.GetNSquare()
+	product, quotient := new(big.Int), new(big.Int)
+	dst.C.Set(cypher[0].C)
+	for _, c := range cypher[1:] {
+		product.Mul(dst.C, c.C)
+		quotient.QuoRem(product, nSquare, dst.C)
+	}
+}
+
 // Mul returns a product of `cypher` and `scalar` without decrypting `cypher`.
 //
 // It's possible because Paillier is a homomorphic encryption scheme, where
@@ -96,30 +364,401 @@ func (pk *PublicKey) Add(cypher ...*Cypher) *Cypher {
 // product of the plaintext `m` and `k`:
 //
 // D( E(m)^k mod N^2 ) = km mod N
+//
+// For `scalar` equal 0, Mul returns a cypher with C = 1 which is a valid,
+// non-randomized, encryption of 0.
 func (pk *PublicKey) Mul(cypher *Cypher, scalar *big.Int) *Cypher {
 	return &Cypher{
 		C: new(big.Int).Exp(cypher.C, scalar, pk.GetNSquare()),
 	}
 }
 
+// Sub returns a cypher encoding the difference of the plaintexts of
+// `cypher1` and `cypher2` modulo N, without decrypting them. `cypher2` is
+// multiplied by N-1, that is by -1 in the plaintext space:
+//
+// D( E(m1) * E(m2)^(N-1) mod N^2 ) = m1 - m2 mod N
+func (pk *PublicKey) Sub(cypher1, cypher2 *Cypher) *Cypher {
+	return pk.Add(cypher1, pk.Mul(cypher2, minusOne(pk.N)))
+}
+
+// DivExact returns a cypher encoding the plaintext of `cypher` divided by
+// `k`, without decrypting `cypher`. The cypher is multiplied by the inverse
+// of `k` modulo N:
+//
+// D( E(m)^(k^-1 mod N) mod N^2 ) = m / k mod N
+//
+// The result is the integer quotient m / k only if `m` is a multiple of `k`.
+// That precondition can't be checked on a cypher, and if it doesn't hold the
+// result decrypts to a meaningless value modulo N instead of a rounded
+// quotient. `ErrNotInvertible` is returned if `k` is not invertible modulo N.
+func (pk *PublicKey) DivExact(cypher *Cypher, k *big.Int) (*Cypher, error) {
+	kInverse, err := mustInverse(new(big.Int).Mod(k, pk.N), pk.N)
+	if err != nil {
+		return nil, err
+	}
+	return pk.Mul(cypher, kInverse), nil
+}
+
+// Increment returns a cypher encoding the plaintext of `cypher` plus one,
+// without decrypting `cypher`. It only needs a single multiplication by g:
+//
+// E(m + 1) = [E(m) * (1 + N)] mod N^2
+func (pk *PublicKey) Increment(cypher *Cypher) *Cypher {
+	g := new(big.Int).Add(pk.N, ONE)
+	return &Cypher{
+		C: new(big.Int).Mod(new(big.Int).Mul(cypher.C, g), pk.GetNSquare()),
+	}
+}
+
+// Decrement returns a cypher encoding the plaintext of `cypher` minus one,
+// without decrypting `cypher`. It only needs a single multiplication by g^-1
+// which always exists and is equal to 1 + (N-1)N mod N^2:
+//
+// E(m - 1) = [E(m) * (1 + (N-1)N)] mod N^2
+func (pk *PublicKey) Decrement(cypher *Cypher) *Cypher {
+	gInverse := new(big.Int).Add(ONE, new(big.Int).Mul(minusOne(pk.N), pk.N))
+	return &Cypher{
+		C: new(big.Int).Mod(new(big.Int).Mul(cypher.C, gInverse), pk.GetNSquare()),
+	}
+}
+
+// MulScalars returns a cypher encoding the product of the plaintext of
+// `cypher` and all the `scalars`, without decrypting `cypher`.
+//
+// The product of scalars is folded modulo N first, so only a single
+// exponentiation is performed. It is much cheaper than chaining `Mul` calls.
+// Negative scalars are reduced modulo N as well, that is, they are treated as
+// their additive inverses in the plaintext space.
+//
+// D( E(m)^(k1*k2*...*kt mod N) mod N^2 ) = m*k1*k2*...*kt mod N
+func (pk *PublicKey) MulScalars(cypher *Cypher, scalars []*big.Int) *Cypher {
+	product := big.NewInt(1)
+	for _, scalar := range scalars {
+		product = new(big.Int).Mod(new(big.Int).Mul(product, scalar), pk.N)
+	}
+
+	return pk.Mul(cypher, product)
+}
+
+// Affine returns a cypher encoding `a*m + b mod N` where `m` is the plaintext
+// of `cypher` and `a`, `b` are public integers, without decrypting `cypher`.
+// It is handy to rescale encrypted data, e.g. for unit conversions.
+//
+// `a` is reduced modulo N. `b` must be in the plaintext space [0, N).
+//
+// E(a*m + b) = [E(m)^a * (1 + N)^b] mod N^2
+func (pk *PublicKey) Affine(cypher *Cypher, a, b *big.Int) (*Cypher, error) {
+	if !pk.InPlaintextSpace(b) {
+		return nil, pk.plaintextSpaceError(b)
+	}
+
+	nSquare := pk.GetNSquare()
+
+	// (1 + N)^b = 1 + bN mod N^2
+	gb := new(big.Int).Add(ONE, new(big.Int).Mul(b, pk.N))
+	ca := new(big.Int).Exp(cypher.C, new(big.Int).Mod(a, pk.N), nSquare)
+
+	return &Cypher{
+		C: new(big.Int).Mod(new(big.Int).Mul(ca, gb), nSquare),
+	}, nil
+}
+
+// Select returns a cypher encoding `x1` if `cypherBit` encrypts 1 and `x0` if
+// it encrypts 0, without decrypting `cypherBit`:
+//
+// E(x0 + b*(x1 - x0)) = E(b ? x1 : x0)
+//
+// It is computed with `Affine`. `x0` and `x1` must be in the plaintext space
+// [0, N). Ensuring `cypherBit` encrypts a bit is the caller's responsibility;
+// for any other plaintext the result is meaningless.
+func (pk *PublicKey) Select(cypherBit *Cypher, x0, x1 *big.Int) (*Cypher, error) {
+	if !pk.InPlaintextSpace(x0) {
+		return nil, pk.plaintextSpaceError(x0)
+	}
+	if !pk.InPlaintextSpace(x1) {
+		return nil, pk.plaintextSpaceError(x1)
+	}
+
+	return pk.Affine(cypherBit, new(big.Int).Sub(x1, x0), x0)
+}
+
+// SubConstant returns a cypher encoding `m - p mod N` where `m` is the
+// plaintext of `cypher` and `p` is a public integer, without decrypting
+// `cypher`. `p` must be in the plaintext space [0, N).
+//
+// E(m - p) = [E(m) * (1 + (N-p)N)] mod N^2
+func (pk *PublicKey) SubConstant(cypher *Cypher, p *big.Int) (*Cypher, error) {
+	if !pk.InPlaintextSpace(p) {
+		return nil, pk.plaintextSpaceError(p)
+	}
+
+	// (1 + N)^-p = (1 + N)^(N-p) = 1 + (N-p)N mod N^2
+	gInverse := new(big.Int).Add(
+		ONE,
+		new(big.Int).Mul(new(big.Int).Sub(pk.N, p), pk.N),
+	)
+	return &Cypher{
+		C: new(big.Int).Mod(new(big.Int).Mul(cypher.C, gInverse), pk.GetNSquare()),
+	}, nil
+}
+
+// SubVector subtracts the plaintexts `plains` from the cyphers `cyphers`
+// elementwise with `SubConstant`, that is, it returns E(m_i - p_i mod N) for
+// each i. Both slices must have the same length.
+func (pk *PublicKey) SubVector(cyphers []*Cypher, plains []*big.Int) ([]*Cypher, error) {
+	if len(cyphers) != len(plains) {
+		return nil, fmt.Errorf(
+			"cyphers and plaintexts have different lengths: %v and %v",
+			len(cyphers),
+			len(plains),
+		)
+	}
+
+	ret := make([]*Cypher, len(cyphers))
+	for i := range cyphers {
+		var err error
+		if ret[i], err = pk.SubConstant(cyphers[i], plains[i]); err != nil {
+			return nil, err
+		}
+	}
+	return ret, nil
+}
+
+// Private key for the Paillier scheme.
+//
+// `Mu` is optional. When it is nil, `Lambda^-1 mod N` is used instead which is
+// valid for keys created with `CreatePrivateKey`. Keys following the `lcm`
+// convention, see `CreatePrivateKeyLCM`, carry an explicit `Mu`.
 type PrivateKey struct {
 	PublicKey
 	Lambda *big.Int
+	Mu     *big.Int
 }
 
 // Decodes ciphertext into a plaintext message.
 //
 // c - cyphertext to decrypt
-// N, lambda - key attributes
+// N, lambda, mu - key attributes
+//
+// D(c) = [ ((c^lambda) mod N^2) - 1) / N ] mu mod N
 //
-// D(c) = [ ((c^lambda) mod N^2) - 1) / N ] lambda^-1 mod N
+// where mu is equal to lambda^-1 mod N if not specified in the key.
 //
 // See [KL 08] construction 11.32, page 414.
+//
+// Decrypt panics if the key is corrupted, see `DecryptChecked`.
 func (priv *PrivateKey) Decrypt(cypher *Cypher) (msg *big.Int) {
-	mu := new(big.Int).ModInverse(priv.Lambda, priv.N)
-	tmp := new(big.Int).Exp(cypher.C, priv.Lambda, priv.GetNSquare())
-	msg = new(big.Int).Mod(new(big.Int).Mul(L(tmp, priv.N), mu), priv.N)
-	return
+	mu, err := priv.mu()
+	if err != nil {
+		panic(err)
+	}
+	return priv.decrypt(cypher, mu, priv.GetNSquare())
+}
+
+func (priv *PrivateKey) decrypt(cypher *Cypher, mu, nSquare *big.Int) *big.Int {
+	tmp := new(big.Int).Exp(cypher.C, priv.Lambda, nSquare)
+	return new(big.Int).Mod(new(big.Int).Mul(L(tmp, priv.N), mu), priv.N)
+}
+
+// DecryptBatch decrypts all `cyphers` and returns the plaintexts in the same
+// order. The cyphers are decrypted concurrently by up to `GOMAXPROCS`
+// routines which makes it much faster than calling `Decrypt` in a loop for
+// bulk workloads on multi-core machines.
+//
+// DecryptBatch panics if the key is corrupted, see `DecryptChecked`.
+func (priv *PrivateKey) DecryptBatch(cyphers []*Cypher) []*big.Int {
+	mu, err := priv.mu()
+	if err != nil {
+		panic(err)
+	}
+	nSquare := priv.GetNSquare()
+
+	plaintexts := make([]*big.Int, len(cyphers))
+	routines := runtime.GOMAXPROCS(0)
+	if routines > len(cyphers) {
+		routines = len(cyphers)
+	}
+
+	var waitGroup sync.WaitGroup
+	waitGroup.Add(routines)
+	for routine := 0; routine < routines; routine++ {
+		go func(routine int) {
+			defer waitGroup.Done()
+			for i := routine; i < len(cyphers); i += routines {
+				plaintexts[i] = priv.decrypt(cyphers[i], mu, nSquare)
+			}
+		}(routine)
+	}
+	waitGroup.Wait()
+
+	return plaintexts
+}
+
+// Returns `Mu` if specified in the key or lambda^-1 mod N otherwise.
+func (priv *PrivateKey) mu() (*big.Int, error) {
+	if priv.Mu != nil {
+		return priv.Mu, nil
+	}
+	mu, err := mustInverse(priv.Lambda, priv.N)
+	if err != nil {
+		return nil, ErrLambdaNotInvertible
+	}
+	return mu, nil
+}
+
+// DecryptChecked decodes ciphertext into a plaintext message like `Decrypt`
+// but validates the cyphertext first. An error is returned if the cyphertext
+// is not an element of the multiplicative group of integers modulo N^2.
+// `ErrLambdaNotInvertible` is returned if the key is corrupted.
+//
+// If `rejectTrivial` is set, `ErrTrivialCypher` is returned for cyphertexts
+// that are powers of g = N+1, that is E(m, r) with r = 1, such as C = 1 which
+// decrypts to 0 or C = N+1 which decrypts to 1. Such cyphertexts are never
+// produced by `Encrypt` but are mathematically valid. An attacker with access
+// to a decryption oracle may submit them to probe the oracle since their
+// plaintext is known in advance.
+func (priv *PrivateKey) DecryptChecked(cypher *Cypher, rejectTrivial bool) (*big.Int, error) {
+	c := cypher.C
+	if c.Cmp(ZERO) != 1 || c.Cmp(priv.GetNSquare()) != -1 { // c <= 0 || c >= N^2 ?
+		return nil, errors.New("cyphertext is out of allowed space (0, N^2)")
+	}
+	if new(big.Int).GCD(nil, nil, c, priv.N).Cmp(ONE) != 0 {
+		return nil, errors.New("cyphertext is not invertible modulo N^2")
+	}
+	// (1+N)^m = 1 + mN mod N^2, so every power of g is equal 1 modulo N
+	if rejectTrivial && new(big.Int).Mod(c, priv.N).Cmp(ONE) == 0 {
+		return nil, ErrTrivialCypher
+	}
+	if _, err := priv.mu(); err != nil {
+		return nil, err
+	}
+	return priv.Decrypt(cypher), nil
+}
+
+// DecryptWithR decrypts `cypher` like `DecryptChecked` and additionally
+// recovers the randomness `r` used to produce it, that is `cypher` = E(m, r).
+// It allows verifying, e.g. in forensic analysis, that a specific `r` has been
+// used, see `VerifyOpening`.
+//
+// Since g = N+1 is equal 1 modulo N:
+//
+// C = (1 + N)^m r^N = r^N mod N
+//
+// `r` is recovered by taking the N-th root of C modulo N, which is possible
+// with the knowledge of Lambda:
+//
+// r = C^(N^-1 mod Lambda) mod N
+//
+// It is the `r` from [1, N) accepted by `EncryptWithR`.
+func (priv *PrivateKey) DecryptWithR(cypher *Cypher) (m *big.Int, r *big.Int, err error) {
+	m, err = priv.DecryptChecked(cypher, false)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	nInverse, err := mustInverse(priv.N, priv.Lambda)
+	if err != nil {
+		return nil, nil, err
+	}
+	r = new(big.Int).Exp(new(big.Int).Mod(cypher.C, priv.N), nInverse, priv.N)
+	return m, r, nil
+}
+
+// DecryptToBytes decrypts `cypher` like `DecryptChecked` and returns the
+// plaintext as a big-endian byte slice of exactly `width` bytes, left-padded
+// with zeros. An error is returned if the plaintext does not fit in `width`
+// bytes.
+func (priv *PrivateKey) DecryptToBytes(cypher *Cypher, width int) ([]byte, error) {
+	m, err := priv.DecryptChecked(cypher, false)
+	if err != nil {
+		return nil, err
+	}
+	if (m.BitLen()+7)/8 > width {
+		return nil, fmt.Errorf("plaintext does not fit in %v bytes", width)
+	}
+	return m.FillBytes(make([]byte, width)), nil
+}
+
+// DecryptSumChecked decrypts the homomorphic sum of `cyphers` and checks it
+// does not exceed `expectedMax`, the maximum total declared by the caller.
+//
+// Plaintexts live modulo N so the sum silently wraps around if the true total
+// is greater than or equal N. The wrap can't be detected directly, but if the
+// individual values are known to be small, a decrypted sum greater than
+// `expectedMax` indicates a probable wrap or a malformed cypher. A wrapped sum
+// which happens to land below `expectedMax` is not detected.
+func (priv *PrivateKey) DecryptSumChecked(cyphers []*Cypher, expectedMax *big.Int) (*big.Int, error) {
+	sum := priv.Decrypt(priv.Add(cyphers...))
+	if sum.Cmp(expectedMax) == 1 {
+		return nil, fmt.Errorf(
+			"decrypted sum %v exceeds the expected maximum %v",
+			sum,
+			expectedMax,
+		)
+	}
+	return sum, nil
+}
+
+// ReEncrypt decrypts `cypher` with this key and encrypts the plaintext under
+// `newKey` in a single call. It is meant for trusted migrations, when the keys
+// are rotated by the holder of the old private key.
+//
+// The plaintext is briefly present in memory; it is zeroized once encrypted
+// but, just like with `Zeroize`, copies may remain. An error is returned if
+// the plaintext does not fit in the plaintext space of `newKey`.
+func (priv *PrivateKey) ReEncrypt(cypher *Cypher, newKey *PublicKey, random RandReader) (*Cypher, error) {
+	m, err := priv.DecryptChecked(cypher, false)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroize(m)
+
+	if !newKey.InPlaintextSpace(m) {
+		return nil, errors.New("plaintext does not fit in the plaintext space of the new key")
+	}
+	return newKey.Encrypt(m, random)
+}
+
+// Validate checks that the private key matches its public key by encrypting
+// a random plaintext and decrypting it back. It catches truncated or
+// mismatched key material, e.g. after deserialization. The method returns nil
+// if the key is well formed or an explicative error otherwise.
+func (priv *PrivateKey) Validate(random RandReader) error {
+	m, err := rand.Int(random, priv.N)
+	if err != nil {
+		return err
+	}
+	c, err := priv.Encrypt(m, random)
+	if err != nil {
+		return err
+	}
+	decrypted, err := priv.DecryptChecked(c, false)
+	if err != nil {
+		return err
+	}
+	if decrypted.Cmp(m) != 0 {
+		return errors.New("private key does not match the public key")
+	}
+	return nil
+}
+
+// Zeroize overwrites the secret material of the key, that is `Lambda` and
+// `Mu`, with zeros and drops the references to it. It should be called once
+// the key is not needed anymore.
+//
+// `N` is public and is shared with every copy of the embedded `PublicKey`,
+// e.g. `pub := priv.PublicKey`, so it is only detached from the private key,
+// not overwritten, and the copies can still be used to encrypt.
+//
+// This is a best-effort operation: the Go garbage collector and big.Int
+// internals may have left copies of the values elsewhere in memory.
+func (priv *PrivateKey) Zeroize() {
+	zeroize(priv.Lambda)
+	zeroize(priv.Mu)
+	priv.Lambda = nil
+	priv.Mu = nil
+	priv.N = nil
 }
 
 type Cypher struct {
@@ -130,6 +769,75 @@ func (this *Cypher) String() string {
 	return fmt.Sprintf("%x", this.C)
 }
 
+// Clone returns a deep copy of the cypher which doesn't share `C` with it.
+func (this *Cypher) Clone() *Cypher {
+	return &Cypher{C: new(big.Int).Set(this.C)}
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler. The cypher is encoded as
+// the 4-byte big-endian length of `C` followed by the big-endian bytes of `C`.
+// An error is returned if `C` is nil or negative.
+func (this *Cypher) MarshalBinary() ([]byte, error) {
+	if this.C == nil {
+		return nil, errors.New("cypher is empty")
+	}
+	if this.C.Sign() < 0 {
+		return nil, errors.New("cypher can not be negative")
+	}
+	c := this.C.Bytes()
+	data := make([]byte, 4+len(c))
+	binary.BigEndian.PutUint32(data, uint32(len(c)))
+	copy(data[4:], c)
+	return data, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler. It decodes data
+// produced by `MarshalBinary`.
+func (this *Cypher) UnmarshalBinary(data []byte) error {
+	if len(data) < 4 {
+		return errors.New("cypher data is too short")
+	}
+	length := binary.BigEndian.Uint32(data)
+	if uint64(len(data)-4) != uint64(length) {
+		return fmt.Errorf(
+			"cypher data length mismatch: expected %v bytes but got %v",
+			length,
+			len(data)-4,
+		)
+	}
+	this.C = new(big.Int).SetBytes(data[4:])
+	return nil
+}
+
+type jsonCypher struct {
+	C string `json:"c"`
+}
+
+// MarshalJSON implements json.Marshaler. The cypher is encoded as
+// {"c":"<hex>"} with `C` in lowercase hexadecimal without any prefix,
+// consistent with the other encodings of the library.
+func (this *Cypher) MarshalJSON() ([]byte, error) {
+	if this.C == nil {
+		return nil, errors.New("cypher is empty")
+	}
+	return json.Marshal(&jsonCypher{this.C.Text(16)})
+}
+
+// UnmarshalJSON implements json.Unmarshaler. It decodes data produced by
+// `MarshalJSON`.
+func (this *Cypher) UnmarshalJSON(data []byte) error {
+	encoded := new(jsonCypher)
+	if err := json.Unmarshal(data, encoded); err != nil {
+		return err
+	}
+	c, ok := new(big.Int).SetString(encoded.C, 16)
+	if !ok || c.Sign() < 0 {
+		return fmt.Errorf("%q is not a valid hexadecimal cypher", encoded.C)
+	}
+	this.C = c
+	return nil
+}
+
 func L(u, n *big.Int) *big.Int {
 	t := new(big.Int).Add(u, big.NewInt(-1))
 	return new(big.Int).Div(t, n)
@@ -170,3 +878,29 @@ func CreatePrivateKey(p, q *big.Int) *PrivateKey {
 		Lambda: lambda,
 	}
 }
+
+// CreatePrivateKeyLCM generates a Paillier private key following the original
+// Paillier convention, where `Lambda = lcm(p-1, q-1)` and
+// `Mu = (L(g^Lambda mod N^2))^-1 mod N` with `g = N+1`.
+//
+// This is the convention used by many other Paillier implementations and lets
+// keys generated by them be imported and used with this library.
+func CreatePrivateKeyLCM(p, q *big.Int) *PrivateKey {
+	n := new(big.Int).Mul(p, q)
+	nSquare := new(big.Int).Mul(n, n)
+
+	pMinusOne, qMinusOne := minusOne(p), minusOne(q)
+	gcd := new(big.Int).GCD(nil, nil, pMinusOne, qMinusOne)
+	lambda := new(big.Int).Div(new(big.Int).Mul(pMinusOne, qMinusOne), gcd)
+
+	g := new(big.Int).Add(n, ONE)
+	mu := new(big.Int).ModInverse(L(new(big.Int).Exp(g, lambda, nSquare), n), n)
+
+	return &PrivateKey{
+		PublicKey: PublicKey{
+			N: n,
+		},
+		Lambda: lambda,
+		Mu:     mu,
+	}
+}
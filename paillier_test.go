@@ -1,8 +1,12 @@
 package paillier
 
 import (
+	"bytes"
 	"crypto/rand"
+	"encoding/json"
 	"errors"
+	"flag"
+	"fmt"
 	"math/big"
 	"reflect"
 	"testing"
@@ -47,6 +51,30 @@ func TestCreatePrivateKey(t *testing.T) {
 	}
 }
 
+func TestCreatePrivateKeyLCM(t *testing.T) {
+	p := big.NewInt(463)
+	q := big.NewInt(631)
+
+	privateKey := CreatePrivateKeyLCM(p, q)
+
+	if privateKey.N.Cmp(big.NewInt(292153)) != 0 {
+		t.Errorf("Unexpected N PublicKey value [%v]", privateKey.N)
+	}
+
+	// lcm(462, 630) = 6930
+	if privateKey.Lambda.Cmp(big.NewInt(6930)) != 0 {
+		t.Errorf("Unexpected Lambda Public key value [%v]", privateKey.Lambda)
+	}
+
+	cypher, err := privateKey.Encrypt(big.NewInt(1234), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m := privateKey.Decrypt(cypher); m.Cmp(big.NewInt(1234)) != 0 {
+		t.Errorf("Unexpected decrypted value [%v]", m)
+	}
+}
+
 func TestEncryptDecryptSmall(t *testing.T) {
 	p := big.NewInt(13)
 	q := big.NewInt(11)
@@ -65,6 +93,213 @@ func TestEncryptDecryptSmall(t *testing.T) {
 	}
 }
 
+func TestEncryptWithRMatchesExp(t *testing.T) {
+	privateKey := CreatePrivateKey(big.NewInt(463), big.NewInt(631))
+	nSquare := privateKey.GetNSquare()
+	g := new(big.Int).Add(privateKey.N, big.NewInt(1))
+
+	for _, m := range []int64{0, 1, 2, 1000, 292152} {
+		// with r = 1, E(m, r) = g^m mod N^2
+		cypher, err := privateKey.EncryptWithR(big.NewInt(m), big.NewInt(1))
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := new(big.Int).Exp(g, big.NewInt(m), nSquare)
+		if cypher.C.Cmp(expected) != 0 {
+			t.Errorf(
+				"Unexpected encryption of %v\nExpected: %v\nActual: %v",
+				m,
+				expected,
+				cypher.C,
+			)
+		}
+	}
+}
+
+func TestEncryptionVector(t *testing.T) {
+	// N = 3 * 5 = 15, N^2 = 225
+	pk := &PublicKey{N: big.NewInt(15)}
+
+	var tests = map[string]struct {
+		vector        *EncryptionVector
+		expectedError error
+	}{
+		// g^m = 1 + 2*15 = 31, r^N = 2^15 mod 225 = 143,
+		// C = 31 * 143 mod 225 = 158
+		"hand-computed": {
+			vector: &EncryptionVector{M: b(2), R: b(2), C: b(158)},
+		},
+		// g^m = 1, r^N = 7^15 mod 225 = 118
+		"zero plaintext": {
+			vector: &EncryptionVector{M: b(0), R: b(7), C: b(118)},
+		},
+		"wrong cypher": {
+			vector: &EncryptionVector{M: b(2), R: b(2), C: b(157)},
+			expectedError: errors.New(
+				"encryption vector mismatch, expected cypher 9d but got 9e",
+			),
+		},
+		"invalid r": {
+			vector:        &EncryptionVector{M: b(2), R: b(3), C: b(158)},
+			expectedError: errors.New("r is not invertible modulo N"),
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			err := pk.CheckEncryptionVector(test.vector)
+			if !reflect.DeepEqual(test.expectedError, err) {
+				t.Errorf(
+					"Unexpected error\nExpected: %v\nActual: %v",
+					test.expectedError,
+					err,
+				)
+			}
+		})
+	}
+}
+
+func TestPublicKeyEqual(t *testing.T) {
+	pk := &PublicKey{N: big.NewInt(143)}
+
+	if !pk.Equal(&PublicKey{N: big.NewInt(143)}) {
+		t.Error("keys with the same N should be equal")
+	}
+	if pk.Equal(&PublicKey{N: big.NewInt(221)}) {
+		t.Error("keys with different N should not be equal")
+	}
+	if pk.Equal(nil) {
+		t.Error("key should not be equal to nil")
+	}
+}
+
+func TestPlaintextSpace(t *testing.T) {
+	privateKey := CreatePrivateKey(big.NewInt(13), big.NewInt(11))
+
+	min, max := privateKey.PlaintextSpace()
+	if min.Cmp(big.NewInt(0)) != 0 || max.Cmp(big.NewInt(143)) != 0 {
+		t.Errorf("Unexpected plaintext space [%v, %v)", min, max)
+	}
+
+	var tests = map[int64]bool{
+		-1:  false,
+		0:   true,
+		142: true,
+		143: false,
+	}
+	for m, expected := range tests {
+		if actual := privateKey.InPlaintextSpace(big.NewInt(m)); actual != expected {
+			t.Errorf(
+				"Unexpected result for %v\nExpected: %v\nActual: %v",
+				m,
+				expected,
+				actual,
+			)
+		}
+	}
+}
+
+func TestEncryptWithInvalidR(t *testing.T) {
+	// N = 13 * 11 = 143
+	privateKey := CreatePrivateKey(big.NewInt(13), big.NewInt(11))
+
+	var tests = map[string]struct {
+		r             *big.Int
+		expectedError error
+	}{
+		"r equal 0": {
+			r:             big.NewInt(0),
+			expectedError: errors.New("r is out of allowed range [1, 143)"),
+		},
+		"r equal N": {
+			r:             big.NewInt(143),
+			expectedError: errors.New("r is out of allowed range [1, 143)"),
+		},
+		"r sharing a factor with N": {
+			r:             big.NewInt(26),
+			expectedError: errors.New("r is not invertible modulo N"),
+		},
+		"r valid": {
+			r: big.NewInt(27),
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			_, err := privateKey.EncryptWithR(big.NewInt(42), test.r)
+			if !reflect.DeepEqual(err, test.expectedError) {
+				t.Errorf(
+					"Unexpected error\nExpected: %v\nActual: %v",
+					test.expectedError,
+					err,
+				)
+			}
+		})
+	}
+}
+
+// countingReader is a RandReader counting the draws of randomness.
+type countingReader struct {
+	reader RandReader
+	reads  int
+}
+
+func (cr *countingReader) Read(p []byte) (int, error) {
+	cr.reads++
+	return cr.reader.Read(p)
+}
+
+func TestEncryptDrawsRandomnessOnce(t *testing.T) {
+	// N = 4292870399 is a 32-bit number so r is drawn from 4 bytes.
+	// r = 0x01010101 = 257 * 65537 is smaller than N and coprime with N so
+	// it is never rejected.
+	privateKey := CreatePrivateKey(big.NewInt(65521), big.NewInt(65519))
+	random := &countingReader{
+		reader: bytes.NewReader(bytes.Repeat([]byte{0x01}, 64)),
+	}
+
+	for i := 1; i <= 3; i++ {
+		if _, err := privateKey.Encrypt(big.NewInt(42), random); err != nil {
+			t.Fatal(err)
+		}
+		if random.reads != i {
+			t.Errorf(
+				"Unexpected number of reads\nExpected: %v\nActual: %v",
+				i,
+				random.reads,
+			)
+		}
+	}
+}
+
+func TestEncryptZero(t *testing.T) {
+	privateKey := CreatePrivateKey(big.NewInt(463), big.NewInt(631))
+
+	cypher, r, err := privateKey.EncryptZero(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if m := privateKey.Decrypt(cypher); m.Sign() != 0 {
+		t.Errorf("Unexpected decrypted value [%v]", m)
+	}
+	if new(big.Int).GCD(nil, nil, cypher.C, privateKey.N).Cmp(ONE) != 0 {
+		t.Errorf("Cypher [%v] is not coprime with N", cypher.C)
+	}
+
+	expected, err := privateKey.EncryptWithR(big.NewInt(0), r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cypher.C.Cmp(expected.C) != 0 {
+		t.Errorf(
+			"Unexpected cypher\nExpected: %v\nActual: %v",
+			expected,
+			cypher,
+		)
+	}
+}
+
 func TestCheckPlaintextSpace(t *testing.T) {
 	p := big.NewInt(13)
 	q := big.NewInt(11)
@@ -124,6 +359,103 @@ func TestCheckPlaintextSpace(t *testing.T) {
 	}
 }
 
+func TestDecryptChecked(t *testing.T) {
+	privateKey := CreatePrivateKey(big.NewInt(13), big.NewInt(11))
+
+	cypher, err := privateKey.Encrypt(big.NewInt(42), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m, err := privateKey.DecryptChecked(cypher, true); err != nil {
+		t.Error(err)
+	} else if m.Cmp(big.NewInt(42)) != 0 {
+		t.Errorf("Unexpected decrypted value [%v]", m)
+	}
+
+	// N^2 = 20449
+	var tests = map[string]struct {
+		c             *big.Int
+		rejectTrivial bool
+		expectedError error
+	}{
+		"cyphertext equal 1": {
+			c:             big.NewInt(1),
+			rejectTrivial: true,
+			expectedError: ErrTrivialCypher,
+		},
+		"cyphertext equal N+1": {
+			c:             big.NewInt(144),
+			rejectTrivial: true,
+			expectedError: ErrTrivialCypher,
+		},
+		"cyphertext equal (N+1)^5": {
+			c:             big.NewInt(716),
+			rejectTrivial: true,
+			expectedError: ErrTrivialCypher,
+		},
+		"cyphertext equal 1 allowed": {
+			c: big.NewInt(1),
+		},
+		"cyphertext equal 0": {
+			c:             big.NewInt(0),
+			expectedError: errors.New("cyphertext is out of allowed space (0, N^2)"),
+		},
+		"cyphertext equal N^2": {
+			c:             big.NewInt(20449),
+			expectedError: errors.New("cyphertext is out of allowed space (0, N^2)"),
+		},
+		"cyphertext not invertible": {
+			c:             big.NewInt(13),
+			expectedError: errors.New("cyphertext is not invertible modulo N^2"),
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			_, err := privateKey.DecryptChecked(&Cypher{test.c}, test.rejectTrivial)
+			if !reflect.DeepEqual(err, test.expectedError) {
+				t.Errorf(
+					"Unexpected error\nExpected: %v\nActual: %v",
+					test.expectedError,
+					err,
+				)
+			}
+		})
+	}
+}
+
+func TestDecryptWithCorruptedKey(t *testing.T) {
+	// N = 13 * 11 = 143, Lambda shares the factor 13 with N
+	privateKey := &PrivateKey{
+		PublicKey: PublicKey{N: big.NewInt(143)},
+		Lambda:    big.NewInt(26),
+	}
+
+	cypher, err := privateKey.Encrypt(big.NewInt(42), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := privateKey.DecryptChecked(cypher, false); err != ErrLambdaNotInvertible {
+		t.Errorf(
+			"Unexpected error\nExpected: %v\nActual: %v",
+			ErrLambdaNotInvertible,
+			err,
+		)
+	}
+
+	defer func() {
+		if r := recover(); r != ErrLambdaNotInvertible {
+			t.Errorf(
+				"Unexpected panic\nExpected: %v\nActual: %v",
+				ErrLambdaNotInvertible,
+				r,
+			)
+		}
+	}()
+	privateKey.Decrypt(cypher)
+}
+
 func TestAddCyphers(t *testing.T) {
 	privateKey := CreatePrivateKey(big.NewInt(17), big.NewInt(13))
 
@@ -139,6 +471,101 @@ func TestAddCyphers(t *testing.T) {
 	}
 }
 
+func TestDecryptSumChecked(t *testing.T) {
+	privateKey := CreatePrivateKey(big.NewInt(7), big.NewInt(5))
+
+	cypher1, _ := privateKey.Encrypt(big.NewInt(30), rand.Reader)
+	cypher2, _ := privateKey.Encrypt(big.NewInt(3), rand.Reader)
+	cypher3, _ := privateKey.Encrypt(big.NewInt(11), rand.Reader)
+
+	sum, err := privateKey.DecryptSumChecked(
+		[]*Cypher{cypher1, cypher2},
+		big.NewInt(33),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sum.Cmp(big.NewInt(33)) != 0 {
+		t.Errorf("Unexpected decrypted value [%v]", sum)
+	}
+
+	// 30 + 11 = 41 wraps to 6 modulo 35 which goes undetected
+	if _, err := privateKey.DecryptSumChecked(
+		[]*Cypher{cypher1, cypher3},
+		big.NewInt(20),
+	); err != nil {
+		t.Error(err)
+	}
+
+	// 3 + 30 + 30 = 63 wraps to 28 modulo 35
+	_, err = privateKey.DecryptSumChecked(
+		[]*Cypher{cypher2, cypher1, cypher1},
+		big.NewInt(20),
+	)
+	expectedError := errors.New("decrypted sum 28 exceeds the expected maximum 20")
+	if !reflect.DeepEqual(expectedError, err) {
+		t.Errorf(
+			"Unexpected error\nExpected: %v\nActual: %v",
+			expectedError,
+			err,
+		)
+	}
+}
+
+func TestAddInto(t *testing.T) {
+	privateKey := CreatePrivateKey(big.NewInt(17), big.NewInt(13))
+
+	var tests = map[string]struct {
+		plaintexts []int64
+	}{
+		"no cyphers": {
+			plaintexts: []int64{},
+		},
+		"single cypher": {
+			plaintexts: []int64{5},
+		},
+		"many cyphers": {
+			plaintexts: []int64{5, 6, 7, 200},
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			cyphers := make([]*Cypher, len(test.plaintexts))
+			for i, m := range test.plaintexts {
+				cypher, err := privateKey.Encrypt(big.NewInt(m), rand.Reader)
+				if err != nil {
+					t.Fatal(err)
+				}
+				cyphers[i] = cypher
+			}
+
+			expected := privateKey.Add(cyphers...)
+			actual := &Cypher{C: big.NewInt(12345)}
+			privateKey.AddInto(actual, cyphers...)
+			if expected.C.Cmp(actual.C) != 0 {
+				t.Errorf(
+					"Unexpected sum\nExpected: %v\nActual: %v",
+					expected,
+					actual,
+				)
+			}
+
+			inPlace := &Cypher{C: big.NewInt(1)}
+			for _, cypher := range cyphers {
+				privateKey.AddInto(inPlace, inPlace, cypher)
+			}
+			if expected.C.Cmp(inPlace.C) != 0 {
+				t.Errorf(
+					"Unexpected in place sum\nExpected: %v\nActual: %v",
+					expected,
+					inPlace,
+				)
+			}
+		})
+	}
+}
+
 func TestAddCypherWithSmallKeyModulus(t *testing.T) {
 	privateKey := CreatePrivateKey(big.NewInt(7), big.NewInt(5))
 
@@ -153,36 +580,1076 @@ func TestAddCypherWithSmallKeyModulus(t *testing.T) {
 	}
 }
 
-func TestMulCypher(t *testing.T) {
+func TestIncrementDecrementCypher(t *testing.T) {
 	privateKey := CreatePrivateKey(big.NewInt(17), big.NewInt(13))
 
-	cypher, err := privateKey.Encrypt(big.NewInt(3), rand.Reader)
+	cypher, err := privateKey.Encrypt(big.NewInt(41), rand.Reader)
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	cypherMultiple := privateKey.Mul(cypher, big.NewInt(7))
-	multiple := privateKey.Decrypt(cypherMultiple)
+	for i := 0; i < 5; i++ {
+		cypher = privateKey.Increment(cypher)
+	}
+	if m := privateKey.Decrypt(cypher); m.Cmp(big.NewInt(46)) != 0 {
+		t.Errorf("Unexpected decrypted value [%v]", m)
+	}
 
-	// 3 * 7 = 21
-	if multiple.Cmp(big.NewInt(21)) != 0 {
-		t.Errorf("Unexpected decrypted value [%v]", multiple)
+	for i := 0; i < 47; i++ {
+		cypher = privateKey.Decrement(cypher)
+	}
+	// (46 - 47) mod 221 = 220
+	if m := privateKey.Decrypt(cypher); m.Cmp(big.NewInt(220)) != 0 {
+		t.Errorf("Unexpected decrypted value [%v]", m)
 	}
 }
 
-func TestMulCypherWithSmallKeyModulus(t *testing.T) {
-	privateKey := CreatePrivateKey(big.NewInt(7), big.NewInt(5))
+func TestAddWithoutCyphers(t *testing.T) {
+	privateKey := CreatePrivateKey(big.NewInt(17), big.NewInt(13))
 
-	cypher, err := privateKey.Encrypt(big.NewInt(30), rand.Reader)
+	sum := privateKey.Add()
+	if sum.C.Cmp(big.NewInt(1)) != 0 {
+		t.Errorf("Unexpected cypher [%v]", sum)
+	}
+	if m := privateKey.Decrypt(sum); m.Sign() != 0 {
+		t.Errorf("Unexpected decrypted value [%v]", m)
+	}
+
+	// E(0) is the neutral element of addition
+	cypher, err := privateKey.Encrypt(big.NewInt(5), rand.Reader)
 	if err != nil {
 		t.Fatal(err)
 	}
+	if m := privateKey.Decrypt(privateKey.Add(cypher, sum)); m.Cmp(big.NewInt(5)) != 0 {
+		t.Errorf("Unexpected decrypted value [%v]", m)
+	}
+}
 
-	cypherMultiple := privateKey.Mul(cypher, big.NewInt(93))
-	multiple := privateKey.Decrypt(cypherMultiple)
+func TestDecryptBatch(t *testing.T) {
+	privateKey := CreatePrivateKey(big.NewInt(463), big.NewInt(631))
 
-	// (30*93) mod (7*5) = 25
-	if multiple.Cmp(big.NewInt(25)) != 0 {
-		t.Errorf("Unexpected decrypted value [%v]", multiple)
+	for _, size := range []int{0, 1, 3, 100} {
+		cyphers := make([]*Cypher, size)
+		for i := range cyphers {
+			cypher, err := privateKey.Encrypt(big.NewInt(int64(i*7)), rand.Reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+			cyphers[i] = cypher
+		}
+
+		plaintexts := privateKey.DecryptBatch(cyphers)
+		if len(plaintexts) != size {
+			t.Fatalf(
+				"Unexpected number of plaintexts\nExpected: %v\nActual: %v",
+				size,
+				len(plaintexts),
+			)
+		}
+		for i, cypher := range cyphers {
+			expected := privateKey.Decrypt(cypher)
+			if plaintexts[i].Cmp(expected) != 0 {
+				t.Errorf(
+					"Unexpected plaintext at %v\nExpected: %v\nActual: %v",
+					i,
+					expected,
+					plaintexts[i],
+				)
+			}
+		}
+	}
+}
+
+func TestAddDoesNotAliasCyphers(t *testing.T) {
+	privateKey := CreatePrivateKey(big.NewInt(17), big.NewInt(13))
+
+	var tests = map[string]struct {
+		plaintexts  []int64
+		expectedSum int64
+	}{
+		"no cyphers": {
+			plaintexts:  []int64{},
+			expectedSum: 0,
+		},
+		"single cypher": {
+			plaintexts:  []int64{5},
+			expectedSum: 5,
+		},
+		"many cyphers": {
+			plaintexts:  []int64{5, 6, 7},
+			expectedSum: 18,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			cyphers := make([]*Cypher, len(test.plaintexts))
+			for i, m := range test.plaintexts {
+				cypher, err := privateKey.Encrypt(big.NewInt(m), rand.Reader)
+				if err != nil {
+					t.Fatal(err)
+				}
+				cyphers[i] = cypher
+			}
+
+			sum := privateKey.Add(cyphers...)
+			for _, cypher := range cyphers {
+				if sum == cypher || sum.C == cypher.C {
+					t.Fatal("sum should not share memory with the arguments")
+				}
+			}
+
+			// mutating the arguments must not change the sum
+			for _, cypher := range cyphers {
+				cypher.C.SetInt64(1)
+			}
+			if m := privateKey.Decrypt(sum); m.Cmp(big.NewInt(test.expectedSum)) != 0 {
+				t.Errorf(
+					"Unexpected decrypted value\nExpected: %v\nActual: %v",
+					test.expectedSum,
+					m,
+				)
+			}
+		})
+	}
+}
+
+func TestMulCypherByZero(t *testing.T) {
+	privateKey := CreatePrivateKey(big.NewInt(17), big.NewInt(13))
+
+	cypher, err := privateKey.Encrypt(big.NewInt(3), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	product := privateKey.Mul(cypher, big.NewInt(0))
+	if product.C.Cmp(big.NewInt(1)) != 0 {
+		t.Errorf("Unexpected cypher [%v]", product)
+	}
+	if m := privateKey.Decrypt(product); m.Sign() != 0 {
+		t.Errorf("Unexpected decrypted value [%v]", m)
+	}
+}
+
+func TestMulCypher(t *testing.T) {
+	privateKey := CreatePrivateKey(big.NewInt(17), big.NewInt(13))
+
+	cypher, err := privateKey.Encrypt(big.NewInt(3), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cypherMultiple := privateKey.Mul(cypher, big.NewInt(7))
+	multiple := privateKey.Decrypt(cypherMultiple)
+
+	// 3 * 7 = 21
+	if multiple.Cmp(big.NewInt(21)) != 0 {
+		t.Errorf("Unexpected decrypted value [%v]", multiple)
+	}
+}
+
+func TestMulCypherWithSmallKeyModulus(t *testing.T) {
+	privateKey := CreatePrivateKey(big.NewInt(7), big.NewInt(5))
+
+	cypher, err := privateKey.Encrypt(big.NewInt(30), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cypherMultiple := privateKey.Mul(cypher, big.NewInt(93))
+	multiple := privateKey.Decrypt(cypherMultiple)
+
+	// (30*93) mod (7*5) = 25
+	if multiple.Cmp(big.NewInt(25)) != 0 {
+		t.Errorf("Unexpected decrypted value [%v]", multiple)
+	}
+}
+
+func TestDivExact(t *testing.T) {
+	privateKey := CreatePrivateKey(big.NewInt(17), big.NewInt(13))
+
+	cypher, err := privateKey.Encrypt(big.NewInt(6), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	quotientCypher, err := privateKey.DivExact(cypher, big.NewInt(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	quotient := privateKey.Decrypt(quotientCypher)
+
+	// 6 / 3 = 2
+	if quotient.Cmp(big.NewInt(2)) != 0 {
+		t.Errorf("Unexpected decrypted value [%v]", quotient)
+	}
+
+	if _, err := privateKey.DivExact(cypher, big.NewInt(13)); err != ErrNotInvertible {
+		t.Errorf(
+			"Unexpected error\nExpected: %v\nActual: %v",
+			ErrNotInvertible,
+			err,
+		)
+	}
+}
+
+func TestSubCyphers(t *testing.T) {
+	privateKey := CreatePrivateKey(big.NewInt(17), big.NewInt(13))
+
+	cypher1, err := privateKey.Encrypt(big.NewInt(20), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cypher2, err := privateKey.Encrypt(big.NewInt(7), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if m := privateKey.Decrypt(privateKey.Sub(cypher1, cypher2)); m.Cmp(big.NewInt(13)) != 0 {
+		t.Errorf("Unexpected decrypted value [%v]", m)
+	}
+	// 7 - 20 wraps around to 221 - 13 = 208
+	if m := privateKey.Decrypt(privateKey.Sub(cypher2, cypher1)); m.Cmp(big.NewInt(208)) != 0 {
+		t.Errorf("Unexpected decrypted value [%v]", m)
+	}
+}
+
+// mockHomomorphic is a Homomorphic "encrypting" plaintexts as themselves.
+type mockHomomorphic struct{}
+
+func (mockHomomorphic) Encrypt(m *big.Int, random RandReader) (*Cypher, error) {
+	return &Cypher{C: new(big.Int).Set(m)}, nil
+}
+
+func (mockHomomorphic) Add(cypher ...*Cypher) *Cypher {
+	sum := big.NewInt(0)
+	for _, c := range cypher {
+		sum.Add(sum, c.C)
+	}
+	return &Cypher{C: sum}
+}
+
+func (mockHomomorphic) Mul(cypher *Cypher, scalar *big.Int) *Cypher {
+	return &Cypher{C: new(big.Int).Mul(cypher.C, scalar)}
+}
+
+func (mockHomomorphic) Sub(cypher1, cypher2 *Cypher) *Cypher {
+	return &Cypher{C: new(big.Int).Sub(cypher1.C, cypher2.C)}
+}
+
+func TestHomomorphicMock(t *testing.T) {
+	// 2*a - b computed by code which does not know the backend
+	compute := func(h Homomorphic, a, b int64) *Cypher {
+		ca, err := h.Encrypt(big.NewInt(a), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cb, err := h.Encrypt(big.NewInt(b), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return h.Sub(h.Mul(ca, big.NewInt(2)), cb)
+	}
+
+	if c := compute(mockHomomorphic{}, 30, 4); c.C.Cmp(big.NewInt(56)) != 0 {
+		t.Errorf("Unexpected mock result [%v]", c.C)
+	}
+
+	privateKey := CreatePrivateKey(big.NewInt(17), big.NewInt(13))
+	if m := privateKey.Decrypt(compute(privateKey, 30, 4)); m.Cmp(big.NewInt(56)) != 0 {
+		t.Errorf("Unexpected decrypted value [%v]", m)
+	}
+}
+
+func TestMulScalars(t *testing.T) {
+	privateKey := CreatePrivateKey(big.NewInt(17), big.NewInt(13))
+
+	cypher, err := privateKey.Encrypt(big.NewInt(3), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scalars := []*big.Int{big.NewInt(7), big.NewInt(-2), big.NewInt(500)}
+
+	chained := cypher
+	for _, scalar := range scalars {
+		chained = privateKey.Mul(chained, scalar)
+	}
+	expected := privateKey.Decrypt(chained)
+
+	// 3 * 7 * (-2) * 500 mod 221 = 216
+	actual := privateKey.Decrypt(privateKey.MulScalars(cypher, scalars))
+	if actual.Cmp(expected) != 0 || actual.Cmp(big.NewInt(216)) != 0 {
+		t.Errorf(
+			"Unexpected decrypted value\nExpected: %v\nActual: %v",
+			expected,
+			actual,
+		)
+	}
+}
+
+func TestAffineCypher(t *testing.T) {
+	privateKey := CreatePrivateKey(big.NewInt(17), big.NewInt(13))
+
+	cypher, err := privateKey.Encrypt(big.NewInt(10), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	affine, err := privateKey.Affine(cypher, big.NewInt(3), big.NewInt(5))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 3 * 10 + 5 = 35
+	if m := privateKey.Decrypt(affine); m.Cmp(big.NewInt(35)) != 0 {
+		t.Errorf("Unexpected decrypted value [%v]", m)
+	}
+
+	affine, err = privateKey.Affine(cypher, big.NewInt(-1), big.NewInt(220))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// (-1 * 10 + 220) mod 221 = 210
+	if m := privateKey.Decrypt(affine); m.Cmp(big.NewInt(210)) != 0 {
+		t.Errorf("Unexpected decrypted value [%v]", m)
+	}
+
+	if _, err := privateKey.Affine(cypher, big.NewInt(3), big.NewInt(221)); err == nil {
+		t.Error("Expected an error for b out of the plaintext space")
+	}
+}
+
+func TestPlaintextBits(t *testing.T) {
+	// N = 221 = 0b11011101
+	publicKey := &PublicKey{N: big.NewInt(221)}
+	if bits := publicKey.PlaintextBits(); bits != 7 {
+		t.Errorf("Unexpected plaintext bits [%v]", bits)
+	}
+
+	// 2^7 - 1 fits, 2^8 - 1 does not
+	if !publicKey.InPlaintextSpace(big.NewInt(127)) {
+		t.Error("Expected 127 to be in the plaintext space")
+	}
+	if publicKey.InPlaintextSpace(big.NewInt(255)) {
+		t.Error("Expected 255 not to be in the plaintext space")
+	}
+}
+
+func TestSelect(t *testing.T) {
+	privateKey := CreatePrivateKey(big.NewInt(17), big.NewInt(13))
+
+	var tests = map[string]struct {
+		bit      int64
+		x0       int64
+		x1       int64
+		expected int64
+	}{
+		"bit is 0": {
+			bit:      0,
+			x0:       120,
+			x1:       35,
+			expected: 120,
+		},
+		"bit is 1": {
+			bit:      1,
+			x0:       120,
+			x1:       35,
+			expected: 35,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			cypherBit, err := privateKey.Encrypt(big.NewInt(test.bit), rand.Reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			selected, err := privateKey.Select(
+				cypherBit,
+				big.NewInt(test.x0),
+				big.NewInt(test.x1),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if m := privateKey.Decrypt(selected); m.Cmp(big.NewInt(test.expected)) != 0 {
+				t.Errorf(
+					"Unexpected decrypted value\nExpected: %v\nActual: %v",
+					test.expected,
+					m,
+				)
+			}
+		})
+	}
+
+	cypherBit, err := privateKey.Encrypt(big.NewInt(1), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := privateKey.Select(cypherBit, big.NewInt(1), big.NewInt(221)); err == nil {
+		t.Error("Expected an error for x1 out of the plaintext space")
+	}
+}
+
+func TestSubVector(t *testing.T) {
+	privateKey := CreatePrivateKey(big.NewInt(17), big.NewInt(13))
+
+	messages := []*big.Int{big.NewInt(10), big.NewInt(100), big.NewInt(3)}
+	cyphers := make([]*Cypher, len(messages))
+	for i, m := range messages {
+		var err error
+		if cyphers[i], err = privateKey.Encrypt(m, rand.Reader); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	differences, err := privateKey.SubVector(
+		cyphers,
+		[]*big.Int{big.NewInt(4), big.NewInt(100), big.NewInt(5)},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 3 - 5 wraps around to 221 - 2 = 219
+	expected := []int64{6, 0, 219}
+	for i, difference := range differences {
+		if m := privateKey.Decrypt(difference); m.Cmp(big.NewInt(expected[i])) != 0 {
+			t.Errorf(
+				"Unexpected decrypted value at index %v\nExpected: %v\nActual: %v",
+				i,
+				expected[i],
+				m,
+			)
+		}
+	}
+
+	if _, err := privateKey.SubVector(cyphers, []*big.Int{big.NewInt(1)}); err == nil {
+		t.Error("Expected an error for vectors of different lengths")
+	}
+	if _, err := privateKey.SubVector(
+		cyphers,
+		[]*big.Int{big.NewInt(1), big.NewInt(221), big.NewInt(1)},
+	); err == nil {
+		t.Error("Expected an error for a plaintext out of the plaintext space")
+	}
+}
+
+func TestValidatePrivateKey(t *testing.T) {
+	privateKey := CreatePrivateKey(big.NewInt(463), big.NewInt(631))
+	if err := privateKey.Validate(rand.Reader); err != nil {
+		t.Errorf("Unexpected error for a valid key: %v", err)
+	}
+
+	privateKey.Lambda = new(big.Int).Add(privateKey.Lambda, big.NewInt(2))
+	if err := privateKey.Validate(rand.Reader); err == nil {
+		t.Error("Expected an error for a key with a corrupted Lambda")
+	}
+}
+
+func TestVerifyOpening(t *testing.T) {
+	privateKey := CreatePrivateKey(big.NewInt(463), big.NewInt(631))
+
+	cypher, err := privateKey.EncryptWithR(big.NewInt(100), big.NewInt(7))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var tests = map[string]struct {
+		m             *big.Int
+		r             *big.Int
+		expectedValid bool
+		expectedError bool
+	}{
+		"correct opening": {
+			m:             big.NewInt(100),
+			r:             big.NewInt(7),
+			expectedValid: true,
+		},
+		"wrong r": {
+			m:             big.NewInt(100),
+			r:             big.NewInt(8),
+			expectedValid: false,
+		},
+		"wrong m": {
+			m:             big.NewInt(101),
+			r:             big.NewInt(7),
+			expectedValid: false,
+		},
+		"r out of range": {
+			m:             big.NewInt(100),
+			r:             big.NewInt(0),
+			expectedError: true,
+		},
+		"m out of range": {
+			m:             big.NewInt(292153),
+			r:             big.NewInt(7),
+			expectedError: true,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			valid, err := privateKey.VerifyOpening(cypher, test.m, test.r)
+			if test.expectedError != (err != nil) {
+				t.Fatalf("Unexpected error [%v]", err)
+			}
+			if valid != test.expectedValid {
+				t.Errorf(
+					"Unexpected opening validity\nExpected: %v\nActual: %v",
+					test.expectedValid,
+					valid,
+				)
+			}
+		})
+	}
+}
+
+func TestCommitAndEncrypt(t *testing.T) {
+	privateKey := CreatePrivateKey(big.NewInt(463), big.NewInt(631))
+	otherKey := CreatePrivateKey(big.NewInt(467), big.NewInt(619))
+
+	commitment, cypher, r, err := privateKey.CommitAndEncrypt(
+		big.NewInt(100),
+		rand.Reader,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if m := privateKey.Decrypt(cypher); m.Cmp(big.NewInt(100)) != 0 {
+		t.Errorf("Unexpected decryption\nExpected: %v\nActual: %v", 100, m)
+	}
+	valid, err := privateKey.VerifyOpening(cypher, big.NewInt(100), r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !valid {
+		t.Error("returned randomness should open the cypher")
+	}
+
+	var tests = map[string]struct {
+		key           *PublicKey
+		m             *big.Int
+		expectedValid bool
+	}{
+		"matching reveal": {
+			key:           &privateKey.PublicKey,
+			m:             big.NewInt(100),
+			expectedValid: true,
+		},
+		"mismatching reveal": {
+			key:           &privateKey.PublicKey,
+			m:             big.NewInt(101),
+			expectedValid: false,
+		},
+		"reveal out of plaintext space": {
+			key:           &privateKey.PublicKey,
+			m:             big.NewInt(-1),
+			expectedValid: false,
+		},
+		"other public key": {
+			key:           &otherKey.PublicKey,
+			m:             big.NewInt(100),
+			expectedValid: false,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			valid := test.key.VerifyCommitment(commitment, test.m)
+			if valid != test.expectedValid {
+				t.Errorf(
+					"Unexpected commitment validity\nExpected: %v\nActual: %v",
+					test.expectedValid,
+					valid,
+				)
+			}
+		})
+	}
+}
+
+func TestEncryptNonZero(t *testing.T) {
+	privateKey := CreatePrivateKey(big.NewInt(463), big.NewInt(631))
+
+	var tests = map[string]struct {
+		m             *big.Int
+		expectedError error
+	}{
+		"zero": {
+			m:             big.NewInt(0),
+			expectedError: errors.New("plaintext must not be zero"),
+		},
+		"one": {
+			m: big.NewInt(1),
+		},
+		"out of plaintext space": {
+			m: privateKey.N,
+			expectedError: fmt.Errorf(
+				"%v is out of allowed plaintext space [0, %v)",
+				privateKey.N,
+				privateKey.N,
+			),
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			cypher, err := privateKey.EncryptNonZero(test.m, rand.Reader)
+			if !reflect.DeepEqual(test.expectedError, err) {
+				t.Fatalf(
+					"Unexpected error\nExpected: %v\nActual: %v",
+					test.expectedError,
+					err,
+				)
+			}
+			if test.expectedError == nil {
+				if m := privateKey.Decrypt(cypher); m.Cmp(test.m) != 0 {
+					t.Errorf(
+						"Unexpected decryption\nExpected: %v\nActual: %v",
+						test.m,
+						m,
+					)
+				}
+			}
+		})
+	}
+}
+
+func TestDecryptWithR(t *testing.T) {
+	var tests = map[string]struct {
+		privateKey *PrivateKey
+	}{
+		"phi convention": {
+			privateKey: CreatePrivateKey(big.NewInt(463), big.NewInt(631)),
+		},
+		"lcm convention": {
+			privateKey: CreatePrivateKeyLCM(big.NewInt(463), big.NewInt(631)),
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			for _, r := range []*big.Int{
+				big.NewInt(1),
+				big.NewInt(7),
+				big.NewInt(292152),
+			} {
+				cypher, err := test.privateKey.EncryptWithR(big.NewInt(100), r)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				m, recovered, err := test.privateKey.DecryptWithR(cypher)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if m.Cmp(big.NewInt(100)) != 0 {
+					t.Errorf(
+						"Unexpected plaintext\nExpected: %v\nActual: %v",
+						100,
+						m,
+					)
+				}
+				if recovered.Cmp(r) != 0 {
+					t.Errorf(
+						"Unexpected randomness\nExpected: %v\nActual: %v",
+						r,
+						recovered,
+					)
+				}
+			}
+		})
+	}
+}
+
+func TestDecryptToBytes(t *testing.T) {
+	privateKey := CreatePrivateKey(big.NewInt(463), big.NewInt(631))
+
+	var tests = map[string]struct {
+		m             int64
+		width         int
+		expected      []byte
+		expectedError bool
+	}{
+		"exact fit": {
+			m:        0x0102,
+			width:    2,
+			expected: []byte{0x01, 0x02},
+		},
+		"needs padding": {
+			m:        0x0102,
+			width:    4,
+			expected: []byte{0x00, 0x00, 0x01, 0x02},
+		},
+		"zero": {
+			m:        0,
+			width:    2,
+			expected: []byte{0x00, 0x00},
+		},
+		"too large": {
+			m:             0x010203,
+			width:         2,
+			expectedError: true,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			cypher, err := privateKey.Encrypt(big.NewInt(test.m), rand.Reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			decrypted, err := privateKey.DecryptToBytes(cypher, test.width)
+			if test.expectedError {
+				if err == nil {
+					t.Error("Expected an error for a plaintext not fitting the width")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(decrypted, test.expected) {
+				t.Errorf(
+					"Unexpected bytes\nExpected: %x\nActual: %x",
+					test.expected,
+					decrypted,
+				)
+			}
+		})
+	}
+}
+
+func TestReEncrypt(t *testing.T) {
+	oldKey := CreatePrivateKey(big.NewInt(463), big.NewInt(631))
+	newKey := CreatePrivateKey(big.NewInt(467), big.NewInt(619))
+
+	cypher, err := oldKey.Encrypt(big.NewInt(100), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	switched, err := oldKey.ReEncrypt(cypher, &newKey.PublicKey, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m := newKey.Decrypt(switched); m.Cmp(big.NewInt(100)) != 0 {
+		t.Errorf(
+			"Unexpected decryption under the new key\nExpected: %v\nActual: %v",
+			100,
+			m,
+		)
+	}
+
+	// 292152 does not fit in N = 143
+	cypher, err = oldKey.Encrypt(big.NewInt(292152), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	smallKey := &PublicKey{N: big.NewInt(143)}
+	if _, err := oldKey.ReEncrypt(cypher, smallKey, rand.Reader); err == nil {
+		t.Error("expected an error for a plaintext not fitting in the new key")
+	}
+}
+
+func TestZeroizePrivateKey(t *testing.T) {
+	privateKey := CreatePrivateKeyLCM(big.NewInt(17), big.NewInt(13))
+	lambda, mu := privateKey.Lambda, privateKey.Mu
+	publicKey := privateKey.PublicKey
+
+	privateKey.Zeroize()
+
+	for _, x := range []*big.Int{lambda, mu} {
+		if x.Sign() != 0 {
+			t.Errorf("Unexpected non-zero value [%v]", x)
+		}
+	}
+	if privateKey.Lambda != nil || privateKey.Mu != nil || privateKey.N != nil {
+		t.Error("references to the key material should be dropped")
+	}
+
+	// the public key shares N with the private key and must remain usable
+	if publicKey.N.Cmp(big.NewInt(221)) != 0 {
+		t.Errorf(
+			"Unexpected public key N\nExpected: %v\nActual: %v",
+			221,
+			publicKey.N,
+		)
+	}
+	if _, err := publicKey.Encrypt(big.NewInt(100), rand.Reader); err != nil {
+		t.Error(err)
+	}
+}
+
+// Public key bit length used by benchmarks. Can be lowered to speed up the
+// benchmark run, e.g. `go test -bench . -benchmark.bitlength 512`.
+var benchmarkBitLength = flag.Int(
+	"benchmark.bitlength",
+	1024,
+	"public key bit length used by benchmarks",
+)
+
+func getBenchmarkPrivateKey(b *testing.B) *PrivateKey {
+	p, err := rand.Prime(rand.Reader, *benchmarkBitLength/2)
+	if err != nil {
+		b.Fatal(err)
+	}
+	q, err := rand.Prime(rand.Reader, *benchmarkBitLength/2)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return CreatePrivateKey(p, q)
+}
+
+func getBenchmarkCypher(b *testing.B, privateKey *PrivateKey, m int64) *Cypher {
+	cypher, err := privateKey.Encrypt(big.NewInt(m), rand.Reader)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return cypher
+}
+
+func BenchmarkEncrypt(b *testing.B) {
+	privateKey := getBenchmarkPrivateKey(b)
+	m := big.NewInt(123456)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := privateKey.Encrypt(m, rand.Reader); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecrypt(b *testing.B) {
+	privateKey := getBenchmarkPrivateKey(b)
+	cypher := getBenchmarkCypher(b, privateKey, 123456)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		privateKey.Decrypt(cypher)
+	}
+}
+
+func BenchmarkAdd(b *testing.B) {
+	privateKey := getBenchmarkPrivateKey(b)
+	cypher1 := getBenchmarkCypher(b, privateKey, 123)
+	cypher2 := getBenchmarkCypher(b, privateKey, 456)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		privateKey.Add(cypher1, cypher2)
+	}
+}
+
+func getBenchmarkCyphers(b *testing.B, privateKey *PrivateKey, count int) []*Cypher {
+	cyphers := make([]*Cypher, count)
+	for i := range cyphers {
+		cyphers[i] = getBenchmarkCypher(b, privateKey, int64(i))
+	}
+	return cyphers
+}
+
+func BenchmarkAdd10000Cyphers(b *testing.B) {
+	privateKey := getBenchmarkPrivateKey(b)
+	cyphers := getBenchmarkCyphers(b, privateKey, 10000)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		privateKey.Add(cyphers...)
+	}
+}
+
+func BenchmarkAddInto10000Cyphers(b *testing.B) {
+	privateKey := getBenchmarkPrivateKey(b)
+	cyphers := getBenchmarkCyphers(b, privateKey, 10000)
+	sum := &Cypher{C: new(big.Int)}
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		privateKey.AddInto(sum, cyphers...)
+	}
+}
+
+func BenchmarkMul(b *testing.B) {
+	privateKey := getBenchmarkPrivateKey(b)
+	cypher := getBenchmarkCypher(b, privateKey, 123)
+	scalar := big.NewInt(456)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		privateKey.Mul(cypher, scalar)
+	}
+}
+
+func BenchmarkDecryptLoop100(b *testing.B) {
+	privateKey := getBenchmarkPrivateKey(b)
+	cyphers := make([]*Cypher, 100)
+	for i := range cyphers {
+		cyphers[i] = getBenchmarkCypher(b, privateKey, int64(i))
+	}
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		for _, cypher := range cyphers {
+			privateKey.Decrypt(cypher)
+		}
+	}
+}
+
+func BenchmarkDecryptBatch100(b *testing.B) {
+	privateKey := getBenchmarkPrivateKey(b)
+	cyphers := make([]*Cypher, 100)
+	for i := range cyphers {
+		cyphers[i] = getBenchmarkCypher(b, privateKey, int64(i))
+	}
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		privateKey.DecryptBatch(cyphers)
+	}
+}
+
+func TestCypherClone(t *testing.T) {
+	cypher := &Cypher{C: big.NewInt(123)}
+
+	clone := cypher.Clone()
+	if clone.C.Cmp(cypher.C) != 0 {
+		t.Errorf("Unexpected clone\nExpected: %v\nActual: %v", cypher, clone)
+	}
+
+	clone.C.SetInt64(456)
+	if cypher.C.Cmp(big.NewInt(123)) != 0 {
+		t.Errorf(
+			"Unexpected original after mutating the clone\nExpected: %v\nActual: %v",
+			123,
+			cypher.C,
+		)
+	}
+}
+
+func TestCypherBinaryMarshalling(t *testing.T) {
+	for _, c := range []*big.Int{
+		big.NewInt(0),
+		big.NewInt(5),
+		big.NewInt(0x01000000), // zero bytes after the leading one
+		new(big.Int).Lsh(big.NewInt(1), 2048),
+	} {
+		cypher := &Cypher{C: c}
+
+		data, err := cypher.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		unmarshalled := new(Cypher)
+		if err := unmarshalled.UnmarshalBinary(data); err != nil {
+			t.Fatal(err)
+		}
+		if unmarshalled.C.Cmp(c) != 0 {
+			t.Errorf(
+				"Unexpected unmarshalling result\nExpected: %v\nActual: %v",
+				cypher,
+				unmarshalled,
+			)
+		}
+	}
+
+	if err := new(Cypher).UnmarshalBinary([]byte{0, 0}); err == nil {
+		t.Error("expected an error for too short data")
+	}
+	if err := new(Cypher).UnmarshalBinary([]byte{0, 0, 0, 2, 1}); err == nil {
+		t.Error("expected an error for length mismatch")
+	}
+	if _, err := new(Cypher).MarshalBinary(); err == nil {
+		t.Error("expected an error for empty cypher")
+	}
+	if _, err := (&Cypher{C: big.NewInt(-5)}).MarshalBinary(); err == nil {
+		t.Error("expected an error for negative cypher")
+	}
+}
+
+func TestCypherJSONMarshalling(t *testing.T) {
+	for _, c := range []*big.Int{
+		big.NewInt(0),
+		big.NewInt(5),
+		new(big.Int).Lsh(big.NewInt(1), 2048),
+	} {
+		cypher := &Cypher{C: c}
+
+		data, err := json.Marshal(cypher)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		unmarshalled := new(Cypher)
+		if err := json.Unmarshal(data, unmarshalled); err != nil {
+			t.Fatal(err)
+		}
+		if unmarshalled.C.Cmp(c) != 0 {
+			t.Errorf(
+				"Unexpected unmarshalling result\nExpected: %v\nActual: %v",
+				cypher,
+				unmarshalled,
+			)
+		}
+	}
+
+	if err := json.Unmarshal([]byte(`{"c":"xyz"}`), new(Cypher)); err == nil {
+		t.Error("expected an error for non-hexadecimal cypher")
+	}
+}
+
+func TestCypherJSONFormat(t *testing.T) {
+	data, err := json.Marshal(&Cypher{C: big.NewInt(0xABCDEF)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{"c":"abcdef"}`
+	if string(data) != expected {
+		t.Errorf(
+			"Unexpected JSON\nExpected: %v\nActual: %v",
+			expected,
+			string(data),
+		)
+	}
+}
+
+func TestTextPublicKey(t *testing.T) {
+	key := &PublicKey{N: big.NewInt(292153)}
+
+	text, err := (*TextPublicKey)(key).MarshalText()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(text) != "47539" {
+		t.Errorf("Unexpected text [%s]", text)
+	}
+
+	parsed := new(TextPublicKey)
+	if err := parsed.UnmarshalText(text); err != nil {
+		t.Fatal(err)
+	}
+	if !key.Equal((*PublicKey)(parsed)) {
+		t.Errorf(
+			"Unexpected unmarshalling result\nExpected: %v\nActual: %v",
+			key,
+			parsed,
+		)
+	}
+
+	for _, malformed := range []string{"", "xyz", "-1f", "0"} {
+		if err := new(TextPublicKey).UnmarshalText([]byte(malformed)); err == nil {
+			t.Errorf("expected an error for %q", malformed)
+		}
 	}
 }
@@ -0,0 +1,118 @@
+package paillier
+
+import (
+	"crypto/sha256"
+	"errors"
+	"math/big"
+)
+
+// EqualityProof is a non-interactive zero-knowledge proof that two cyphers
+// produced with the same public key encrypt the same plaintext, without
+// revealing it.
+//
+// If `cypher1` = E(m, r1) and `cypher2` = E(m, r2), the quotient
+// u = cypher1 / cypher2 mod N^2 = (r1 / r2)^N mod N^2 is an encryption of zero.
+// The proof shows the knowledge of rho = r1 / r2 mod N such that u = rho^N
+// mod N^2, which exists if and only if u encrypts zero:
+//
+//    prover:   a = s^N mod N^2 for a random s
+//              e = H(N, cypher1, cypher2, a)
+//              z = s * rho^e mod N
+//    verifier: z^N = a * u^e mod N^2
+//
+// The challenge is a 256-bit hash so the proof is sound only when both prime
+// factors of N are larger than 2^256, which holds for keys of recommended
+// sizes.
+type EqualityProof struct {
+	A *big.Int
+	Z *big.Int
+}
+
+// ProvePlaintextEquality produces `EqualityProof` that `cypher1` and `cypher2`
+// encrypt the same plaintext. The prover must know the openings of both
+// cyphers, that is the plaintext `m` and the randomness `r1` and `r2` used to
+// produce `cypher1` = E(m, r1) and `cypher2` = E(m, r2). An error is returned
+// if any of the openings doesn't match its cypher.
+func ProvePlaintextEquality(
+	pk *PublicKey,
+	cypher1, cypher2 *Cypher,
+	m, r1, r2 *big.Int,
+	random RandReader,
+) (*EqualityProof, error) {
+	for _, opening := range []struct {
+		cypher *Cypher
+		r      *big.Int
+	}{
+		{cypher1, r1},
+		{cypher2, r2},
+	} {
+		valid, err := pk.VerifyOpening(opening.cypher, m, opening.r)
+		if err != nil {
+			return nil, err
+		}
+		if !valid {
+			return nil, errors.New("cypher is not the encryption of m with r")
+		}
+	}
+
+	rho, err := mustInverse(r2, pk.N)
+	if err != nil {
+		return nil, err
+	}
+	rho = new(big.Int).Mod(new(big.Int).Mul(r1, rho), pk.N)
+
+	s, err := GetRandomNumberInMultiplicativeGroup(pk.N, random)
+	if err != nil {
+		return nil, err
+	}
+
+	proof := new(EqualityProof)
+	proof.A = new(big.Int).Exp(s, pk.N, pk.GetNSquare())
+	e := computeEqualityChallenge(pk, cypher1, cypher2, proof.A)
+	proof.Z = new(big.Int).Mod(
+		new(big.Int).Mul(s, new(big.Int).Exp(rho, e, pk.N)),
+		pk.N,
+	)
+	return proof, nil
+}
+
+// VerifyPlaintextEquality checks `proof` produced by `ProvePlaintextEquality`
+// that `cypher1` and `cypher2` encrypt the same plaintext under `pk`.
+func VerifyPlaintextEquality(
+	pk *PublicKey,
+	cypher1, cypher2 *Cypher,
+	proof *EqualityProof,
+) bool {
+	if proof == nil || proof.A == nil || proof.Z == nil {
+		return false
+	}
+
+	nSquare := pk.GetNSquare()
+	if proof.A.Sign() <= 0 || proof.A.Cmp(nSquare) >= 0 ||
+		proof.Z.Sign() <= 0 || proof.Z.Cmp(pk.N) >= 0 {
+		return false
+	}
+
+	inverse, err := mustInverse(cypher2.C, nSquare)
+	if err != nil {
+		return false
+	}
+	u := new(big.Int).Mod(new(big.Int).Mul(cypher1.C, inverse), nSquare)
+
+	e := computeEqualityChallenge(pk, cypher1, cypher2, proof.A)
+	left := new(big.Int).Exp(proof.Z, pk.N, nSquare)
+	right := new(big.Int).Mod(
+		new(big.Int).Mul(proof.A, new(big.Int).Exp(u, e, nSquare)),
+		nSquare,
+	)
+	return left.Cmp(right) == 0
+}
+
+func computeEqualityChallenge(pk *PublicKey, cypher1, cypher2 *Cypher, a *big.Int) *big.Int {
+	hash := sha256.New()
+	hash.Write(pk.N.Bytes())
+	hash.Write(cypher1.C.Bytes())
+	hash.Write(cypher2.C.Bytes())
+	hash.Write(a.Bytes())
+	return new(big.Int).SetBytes(hash.Sum([]byte{}))
+}
@@ -0,0 +1,109 @@
+package paillier
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+func TestPlaintextEquality(t *testing.T) {
+	privateKey := CreatePrivateKey(big.NewInt(463), big.NewInt(631))
+	pk := &privateKey.PublicKey
+
+	cypher1, err := pk.EncryptWithR(big.NewInt(100), big.NewInt(7))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cypher2, err := pk.EncryptWithR(big.NewInt(100), big.NewInt(11))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cypher3, err := pk.EncryptWithR(big.NewInt(101), big.NewInt(11))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proof, err := ProvePlaintextEquality(
+		pk,
+		cypher1,
+		cypher2,
+		big.NewInt(100),
+		big.NewInt(7),
+		big.NewInt(11),
+		rand.Reader,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var tests = map[string]struct {
+		cypher1       *Cypher
+		cypher2       *Cypher
+		proof         *EqualityProof
+		expectedValid bool
+	}{
+		"equal plaintexts": {
+			cypher1:       cypher1,
+			cypher2:       cypher2,
+			proof:         proof,
+			expectedValid: true,
+		},
+		"unequal plaintexts": {
+			cypher1:       cypher1,
+			cypher2:       cypher3,
+			proof:         proof,
+			expectedValid: false,
+		},
+		"tampered proof": {
+			cypher1:       cypher1,
+			cypher2:       cypher2,
+			proof:         &EqualityProof{A: proof.A, Z: big.NewInt(1)},
+			expectedValid: false,
+		},
+		"no proof": {
+			cypher1:       cypher1,
+			cypher2:       cypher2,
+			proof:         nil,
+			expectedValid: false,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			valid := VerifyPlaintextEquality(pk, test.cypher1, test.cypher2, test.proof)
+			if valid != test.expectedValid {
+				t.Errorf(
+					"Unexpected proof validity\nExpected: %v\nActual: %v",
+					test.expectedValid,
+					valid,
+				)
+			}
+		})
+	}
+}
+
+func TestProvePlaintextEqualityOfUnequalPlaintexts(t *testing.T) {
+	privateKey := CreatePrivateKey(big.NewInt(463), big.NewInt(631))
+	pk := &privateKey.PublicKey
+
+	cypher1, err := pk.EncryptWithR(big.NewInt(100), big.NewInt(7))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cypher2, err := pk.EncryptWithR(big.NewInt(101), big.NewInt(11))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ProvePlaintextEquality(
+		pk,
+		cypher1,
+		cypher2,
+		big.NewInt(100),
+		big.NewInt(7),
+		big.NewInt(11),
+		rand.Reader,
+	); err == nil {
+		t.Error("expected an error for cyphers of unequal plaintexts")
+	}
+}
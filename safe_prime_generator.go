@@ -14,24 +14,101 @@ import (
 	"fmt"
 	"io"
 	"math/big"
+	"math/bits"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 // smallPrimes is a list of small, prime numbers that allows us to rapidly
 // exclude some fraction of composite candidates when searching for a random
-// prime. This list is truncated at the point where smallPrimesProduct exceeds
-// a uint64. It does not include two because we ensure that the candidates are
-// odd by construction.
-var smallPrimes = []uint8{
+// prime. This list is truncated at the point where the product of its
+// elements exceeds a uint64. It does not include two because we ensure that
+// the candidates are odd by construction.
+var smallPrimes = []uint64{
 	3, 5, 7, 11, 13, 17, 19, 23, 29, 31, 37, 41, 43, 47, 53,
 }
 
-// smallPrimesProduct is the product of the values in smallPrimes and allows us
-// to reduce a candidate prime by this number and then determine whether it's
-// coprime to all the elements of smallPrimes without further big.Int
-// operations.
-var smallPrimesProduct = new(big.Int).SetUint64(16294579238595022365)
+// defaultSieve is the sieve of `smallPrimes` used unless `WithSmallPrimes`
+// is supplied.
+var defaultSieve = newSmallPrimesSieve(smallPrimes)
+
+// smallPrimesSieve splits a list of small primes into groups such that the
+// product of the primes in each group fits in a uint64. A candidate prime is
+// reduced by the product of every group and then checked for being coprime
+// to all the elements of the group without further big.Int operations.
+type smallPrimesSieve struct {
+	groups []smallPrimesGroup
+}
+
+type smallPrimesGroup struct {
+	primes  []uint64
+	product *big.Int
+}
+
+func newSmallPrimesSieve(primes []uint64) *smallPrimesSieve {
+	sieve := new(smallPrimesSieve)
+	group := smallPrimesGroup{}
+	product := uint64(1)
+	for _, prime := range primes {
+		if hi, lo := bits.Mul64(product, prime); hi == 0 {
+			product = lo
+		} else {
+			group.product = new(big.Int).SetUint64(product)
+			sieve.groups = append(sieve.groups, group)
+			group = smallPrimesGroup{}
+			product = prime
+		}
+		group.primes = append(group.primes, prime)
+	}
+	group.product = new(big.Int).SetUint64(product)
+	sieve.groups = append(sieve.groups, group)
+	return sieve
+}
+
+// SafePrimeOption configures the safe prime search of `GenerateSafePrime`.
+type SafePrimeOption func(*safePrimeConfig) error
+
+type safePrimeConfig struct {
+	sieve *smallPrimesSieve
+}
+
+// WithSmallPrimes replaces the list of small primes used to exclude
+// composite candidates by trial division before the expensive primality
+// tests, see `runGenPrimeRoutine`. All the elements of `primes` must be odd
+// primes.
+//
+// A longer list, e.g. all the odd primes up to 1000, rejects more composite
+// candidates cheaply: the fraction of candidates reaching the primality tests
+// falls roughly like 1/log(B)^2, where B is the largest prime of the list.
+// On the other hand, every candidate costs one big.Int division per 64 bits
+// of the product of the list plus one uint64 division per prime, and the list
+// is kept in memory. Past some length, which depends on `bitLen`, the trial
+// division costs more than the primality tests it saves. A safe prime whose
+// `q` is an element of the list may be skipped, so the list should contain
+// only primes much shorter than the safe primes generated.
+func WithSmallPrimes(primes []uint64) SafePrimeOption {
+	return func(config *safePrimeConfig) error {
+		if len(primes) == 0 {
+			return errors.New("small primes list can not be empty")
+		}
+		for _, prime := range primes {
+			if prime == 2 || !new(big.Int).SetUint64(prime).ProbablyPrime(20) {
+				return fmt.Errorf("%v is not an odd prime", prime)
+			}
+		}
+		config.sieve = newSmallPrimesSieve(primes)
+		return nil
+	}
+}
+
+// MaxSafePrimeBitLen is the maximum bit length of a safe prime accepted by
+// `GenerateSafePrime`. It is twice the length needed for the biggest keys in
+// practical use; safe primes of such a size already take a very long time to
+// be found. Longer primes are rejected immediately instead of letting the
+// search exhaust resources for hours.
+const MaxSafePrimeBitLen = 16384
 
 // GenerateSafePrime tries to find a safe prime concurrently.
 // The returned result is a safe prime `p` and prime `q` such that `p=2q+1`.
@@ -55,6 +132,12 @@ var smallPrimesProduct = new(big.Int).SetUint64(16294579238595022365)
 // `2` and for 2048-bit safe prime, `concurrencyLevel` must be set to at least
 // `4` to get the result in a reasonable time.
 //
+// With several routines, the order in which they consume bytes from `random`
+// is not deterministic, so the prime found can't be reproduced even with
+// a fixed reader. `concurrencyLevel` set to `0` selects the deterministic mode:
+// a single routine consumes `random` sequentially and the same reader content
+// always yields the same prime. It is meant for golden tests and debugging.
+//
 // This function generates safe primes of at least 6 `bitLen`. For every
 // generated safe prime, the two most significant bits are always set to `1`
 // - we don't want the generated number to be too small.
@@ -62,12 +145,174 @@ func GenerateSafePrime(
 	bitLen int,
 	concurrencyLevel int,
 	timeout time.Duration,
-	random io.Reader,
+	random RandReader,
+	options ...SafePrimeOption,
 ) (*big.Int, *big.Int, error) {
+	stats, err := GenerateSafePrimeWithStats(
+		bitLen, concurrencyLevel, timeout, random, options...,
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	return stats.P, stats.Q, nil
+}
+
+// SafePrimeStats is the result of `GenerateSafePrimeWithStats`. Besides the
+// safe prime `P = 2Q + 1`, it describes how the search went, which helps to
+// tune `concurrencyLevel` and `timeout` empirically.
+type SafePrimeStats struct {
+	P *big.Int
+	Q *big.Int
+
+	Elapsed    time.Duration // the time it took to find the safe prime
+	Candidates int64         // random candidates drawn by all the routines
+	Routine    int           // index of the routine which found the prime
+}
+
+// GenerateSafePrimeWithStats works exactly like `GenerateSafePrime` but
+// returns the statistics of the search together with the safe prime.
+func GenerateSafePrimeWithStats(
+	bitLen int,
+	concurrencyLevel int,
+	timeout time.Duration,
+	random RandReader,
+	options ...SafePrimeOption,
+) (*SafePrimeStats, error) {
+	return generateSafePrime(
+		context.Background(), bitLen, concurrencyLevel, timeout, random, options,
+	)
+}
+
+// GenerateSafePrimeContext works exactly like `GenerateSafePrime` but the
+// search can also be aborted with `ctx`, in which case `ctx.Err()` is
+// returned.
+func GenerateSafePrimeContext(
+	ctx context.Context,
+	bitLen int,
+	concurrencyLevel int,
+	timeout time.Duration,
+	random RandReader,
+	options ...SafePrimeOption,
+) (*big.Int, *big.Int, error) {
+	stats, err := generateSafePrime(
+		ctx, bitLen, concurrencyLevel, timeout, random, options,
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	return stats.P, stats.Q, nil
+}
+
+// SafePrimePair is a safe prime `P = 2Q + 1` returned by `GenerateSafePrimes`.
+type SafePrimePair struct {
+	P *big.Int
+	Q *big.Int
+}
+
+// GenerateSafePrimes searches for `count` distinct safe primes at once, with
+// the same parameters as `GenerateSafePrime`. The routines keep searching
+// until all of them are found, so several safe primes are obtained in one
+// shot. `timeout` applies to the whole search.
+//
+// The safe primes are returned sorted by `P` in ascending order. It makes the
+// result independent of which routine found which prime first, so that,
+// e.g., taking the smallest of `count` safe primes is a deterministic
+// tie-break between routines racing to find them, useful for reproducible
+// benchmarks.
+func GenerateSafePrimes(
+	count int,
+	bitLen int,
+	concurrencyLevel int,
+	timeout time.Duration,
+	random RandReader,
+	options ...SafePrimeOption,
+) ([]*SafePrimePair, error) {
+	if count < 1 {
+		return nil, errors.New("count of safe primes must be at least 1")
+	}
+
+	results, err := searchSafePrimes(
+		context.Background(),
+		count,
+		bitLen,
+		concurrencyLevel,
+		timeout,
+		random,
+		options,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	pairs := make([]*SafePrimePair, len(results))
+	for i, result := range results {
+		pairs[i] = &SafePrimePair{P: result.P, Q: result.Q}
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		return pairs[i].P.Cmp(pairs[j].P) < 0
+	})
+	return pairs, nil
+}
+
+func generateSafePrime(
+	parent context.Context,
+	bitLen int,
+	concurrencyLevel int,
+	timeout time.Duration,
+	random RandReader,
+	options []SafePrimeOption,
+) (*SafePrimeStats, error) {
+	results, err := searchSafePrimes(
+		parent, 1, bitLen, concurrencyLevel, timeout, random, options,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return results[0], nil
+}
+
+// Searches for `count` distinct safe primes and returns them in the order in
+// which they have been found.
+func searchSafePrimes(
+	parent context.Context,
+	count int,
+	bitLen int,
+	concurrencyLevel int,
+	timeout time.Duration,
+	random RandReader,
+	options []SafePrimeOption,
+) ([]*SafePrimeStats, error) {
 	if bitLen < 6 {
-		return nil, nil, errors.New("safe prime size must be at least 6 bits")
+		return nil, errors.New("safe prime size must be at least 6 bits")
+	}
+	if bitLen > MaxSafePrimeBitLen {
+		return nil, fmt.Errorf(
+			"safe prime size must be at most %v bits",
+			MaxSafePrimeBitLen,
+		)
+	}
+
+	if concurrencyLevel < 0 {
+		return nil, errors.New("concurrency level can not be negative")
+	}
+	// In the deterministic mode, see `GenerateSafePrime`, the only routine
+	// stops reading `random` as soon as it has found all the safe primes.
+	limit := 0
+	if concurrencyLevel == 0 {
+		concurrencyLevel = 1
+		limit = count
+	}
+
+	config := &safePrimeConfig{sieve: defaultSieve}
+	for _, option := range options {
+		if err := option(config); err != nil {
+			return nil, err
+		}
 	}
 
+	start := time.Now()
+	var candidates int64
+
 	primeChan := make(chan safePrime, concurrencyLevel)
 	errChan := make(chan error, concurrencyLevel)
 
@@ -77,12 +322,13 @@ func GenerateSafePrime(
 	defer close(errChan)
 	defer waitGroup.Wait()
 
-	ctx, cancel := context.WithCancel(context.Background())
+	ctx, cancel := context.WithCancel(parent)
 
 	for i := 0; i < concurrencyLevel; i++ {
 		waitGroup.Add(1)
 		runGenPrimeRoutine(
-			ctx, primeChan, errChan, waitGroup, random, bitLen,
+			ctx, primeChan, errChan, waitGroup, random, bitLen, i, &candidates,
+			config.sieve, limit,
 		)
 	}
 
@@ -92,27 +338,51 @@ func GenerateSafePrime(
 		cancel()
 	}()
 
-	select {
-	case result := <-primeChan:
-		cancel()
-		return result.p, result.q, nil
-	case err := <-errChan:
-		cancel()
-		return nil, nil, err
-	case <-ctx.Done():
-		return nil, nil, fmt.Errorf("generator timed out after %v", timeout)
+	results := make([]*SafePrimeStats, 0, count)
+	found := make(map[string]bool)
+	for len(results) < count {
+		select {
+		case result := <-primeChan:
+			if found[string(result.p.Bytes())] {
+				continue
+			}
+			found[string(result.p.Bytes())] = true
+			results = append(results, &SafePrimeStats{
+				P:          result.p,
+				Q:          result.q,
+				Elapsed:    time.Since(start),
+				Candidates: atomic.LoadInt64(&candidates),
+				Routine:    result.routine,
+			})
+		case err := <-errChan:
+			cancel()
+			return nil, err
+		case <-ctx.Done():
+			if err := parent.Err(); err != nil {
+				return nil, err
+			}
+			return nil, fmt.Errorf("generator timed out after %v", timeout)
+		}
 	}
+	cancel()
+	return results, nil
 }
 
 type safePrime struct {
 	p *big.Int // p = 2q + 1
 	q *big.Int
+
+	routine int // index of the routine which found the prime
 }
 
 // Starts a Goroutine searching for a safe prime of the specified `pBitLen`.
-// If succeeds, writes prime `p` and prime `q` such that `p = 2q+1` to the
-// `primeChan`. Prime `p` has a bit length equal to `pBitLen` and prime `q` has
-// a bit length equal to `pBitLen-1`.
+// Every time it succeeds, writes prime `p` and prime `q` such that `p = 2q+1`
+// to the `primeChan` and searches for the next one until `ctx` is done.
+// Prime `p` has a bit length equal to `pBitLen` and prime `q` has a bit length
+// equal to `pBitLen-1`. Every random candidate drawn is counted in
+// `candidates`. `sieve` holds the `smallPrimes` used in points 2 and 4.
+// If `limit` is positive, the routine returns right after it has written
+// `limit` distinct safe primes, without reading `rand` any further.
 //
 // The algorithm is as follows:
 // 1. Generate a random odd number `q` of length `pBitLen-1` with two the most
@@ -142,15 +412,19 @@ type safePrime struct {
 //    is, we execute Fermat primality test to base 2 checking whether
 //    `2^{p-1} = 1 (mod p)`. It's significantly faster than running full
 //    Miller-Rabin and Baillie-PSW for `p`.
-//    If `q` and `p` are found to be prime, return them as a result. If not, go
-//    back to the point 1.
+//    If `q` and `p` are found to be prime, write them as a result. In any
+//    case, go back to the point 1.
 func runGenPrimeRoutine(
 	ctx context.Context,
 	primeChan chan safePrime,
 	errChan chan error,
 	waitGroup *sync.WaitGroup,
-	rand io.Reader,
+	rand RandReader,
 	pBitLen int,
+	routine int,
+	candidates *int64,
+	sieve *smallPrimesSieve,
+	limit int,
 ) {
 	qBitLen := pBitLen - 1
 	b := uint(qBitLen % 8)
@@ -163,6 +437,9 @@ func runGenPrimeRoutine(
 	q := new(big.Int)
 
 	bigMod := new(big.Int)
+	mods := make([]uint64, len(sieve.groups))
+
+	sent := make(map[string]bool)
 
 	go func() {
 		defer waitGroup.Done()
@@ -177,6 +454,7 @@ func runGenPrimeRoutine(
 					errChan <- err
 					return
 				}
+				atomic.AddInt64(candidates, 1)
 
 				// Clear bits in the first byte to make sure the candidate has
 				// a size <= bits.
@@ -205,15 +483,18 @@ func runGenPrimeRoutine(
 				// a multiple of any of these primes we add two until it isn't.
 				// The probability of overflowing is minimal and can be ignored
 				// because we still perform Miller-Rabin tests on the result.
-				bigMod.Mod(q, smallPrimesProduct)
-				mod := bigMod.Uint64()
+				for i, group := range sieve.groups {
+					mods[i] = bigMod.Mod(q, group.product).Uint64()
+				}
 
 			NextDelta:
 				for delta := uint64(0); delta < 1<<20; delta += 2 {
-					m := mod + delta
-					for _, prime := range smallPrimes {
-						if m%uint64(prime) == 0 && (qBitLen > 6 || m != uint64(prime)) {
-							continue NextDelta
+					for i, group := range sieve.groups {
+						m := mods[i] + delta
+						for _, prime := range group.primes {
+							if m%prime == 0 && (qBitLen > bits.Len64(prime) || m != prime) {
+								continue NextDelta
+							}
 						}
 					}
 
@@ -243,7 +524,7 @@ func runGenPrimeRoutine(
 					// p = 2q+1
 					p.Mul(q, big.NewInt(2))
 					p.Add(p, big.NewInt(1))
-					if !isPrimeCandidate(p) {
+					if !isPrimeCandidate(p, sieve) {
 						continue NextDelta
 					}
 
@@ -254,22 +535,50 @@ func runGenPrimeRoutine(
 				// the number to be one bit too long. Thus we check BitLen
 				// here.
 				if q.ProbablyPrime(20) &&
-					isPocklingtonCriterionSatisfied(p) &&
+					SatisfiesPocklington(p) &&
 					q.BitLen() == qBitLen {
 
-					primeChan <- safePrime{p, q}
-					return
+					if limit > 0 && sent[string(p.Bytes())] {
+						continue
+					}
+
+					// p and q are reused for the next candidates
+					select {
+					case primeChan <- safePrime{
+						new(big.Int).Set(p), new(big.Int).Set(q), routine,
+					}:
+					case <-ctx.Done():
+						return
+					}
+
+					if limit > 0 {
+						sent[string(p.Bytes())] = true
+						if len(sent) == limit {
+							return
+						}
+					}
 				}
 			}
 		}
 	}()
 }
 
-// Pocklington's criterion can be used to prove the primality of `p = 2q + 1`
-// once one has proven the primality of `q`.
-// With `q` prime, `p = 2q + 1`, and `p` passing Fermat's primality test to base
-// `2` that `2^{p-1} = 1 (mod p)` then `p` is prime as well.
-func isPocklingtonCriterionSatisfied(p *big.Int) bool {
+// SatisfiesPocklington checks Pocklington's criterion which proves the
+// primality of the safe prime candidate `p = 2q + 1` once the primality of `q`
+// has been established, e.g. with `q.ProbablyPrime`. It is cheaper than a full
+// Miller-Rabin test of `p`: a single modular exponentiation is needed.
+//
+// With `q` a prime factor of `p - 1` larger than `sqrt(p) - 1`, `p` is prime
+// if there is a witness `a` such that `a^{p-1} = 1 (mod p)` and
+// `gcd(a^{(p-1)/q} - 1, p) = 1`. For the witness `a = 2` used here, the latter
+// condition means that `p` is not divisible by `3`.
+//
+// The primality of `q = (p - 1) / 2` is assumed and not checked. If `q` is not
+// prime, the result says nothing about the primality of `p`.
+func SatisfiesPocklington(p *big.Int) bool {
+	if new(big.Int).Mod(p, big.NewInt(3)).Sign() == 0 {
+		return false
+	}
 	return new(big.Int).Exp(
 		big.NewInt(2),
 		new(big.Int).Sub(p, big.NewInt(1)),
@@ -277,12 +586,14 @@ func isPocklingtonCriterionSatisfied(p *big.Int) bool {
 	).Cmp(big.NewInt(1)) == 0
 }
 
-func isPrimeCandidate(number *big.Int) bool {
-	m := new(big.Int).Mod(number, smallPrimesProduct).Uint64()
-
-	for _, prime := range smallPrimes {
-		if m%uint64(prime) == 0 && m != uint64(prime) {
-			return false
+func isPrimeCandidate(number *big.Int, sieve *smallPrimesSieve) bool {
+	mod := new(big.Int)
+	for _, group := range sieve.groups {
+		m := mod.Mod(number, group.product).Uint64()
+		for _, prime := range group.primes {
+			if m%prime == 0 && m != prime {
+				return false
+			}
 		}
 	}
 
@@ -3,6 +3,8 @@ package paillier
 import (
 	"crypto/rand"
 	"errors"
+	"math/big"
+	mrand "math/rand"
 	"reflect"
 	"testing"
 	"time"
@@ -31,6 +33,11 @@ func TestAsyncGenerator(t *testing.T) {
 			timeout:       1 * time.Second,
 			expectedError: errors.New("safe prime size must be at least 6 bits"),
 		},
+		"bit length exceeds the maximum": {
+			bitLen:        MaxSafePrimeBitLen + 1,
+			timeout:       60 * time.Second,
+			expectedError: errors.New("safe prime size must be at most 16384 bits"),
+		},
 		"bit length is 6": {
 			bitLen:        6,
 			timeout:       60 * time.Second,
@@ -65,3 +72,218 @@ func TestAsyncGenerator(t *testing.T) {
 		})
 	}
 }
+
+func TestGenerateSafePrimeWithStats(t *testing.T) {
+	concurrencyLevel := 2
+
+	stats, err := GenerateSafePrimeWithStats(
+		64,
+		concurrencyLevel,
+		60*time.Second,
+		rand.Reader,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	IsSafePrime(stats.P, stats.Q, 64, t)
+	if stats.Candidates <= 0 {
+		t.Errorf("Unexpected number of candidates [%v]", stats.Candidates)
+	}
+	if stats.Elapsed <= 0 {
+		t.Errorf("Unexpected elapsed time [%v]", stats.Elapsed)
+	}
+	if stats.Routine < 0 || stats.Routine >= concurrencyLevel {
+		t.Errorf("Unexpected routine [%v]", stats.Routine)
+	}
+}
+
+func TestGenerateSafePrimeDeterministic(t *testing.T) {
+	generate := func() (*big.Int, *big.Int) {
+		p, q, err := GenerateSafePrime(
+			128,
+			0,
+			60*time.Second,
+			mrand.New(mrand.NewSource(42)),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return p, q
+	}
+
+	p1, q1 := generate()
+	p2, q2 := generate()
+
+	IsSafePrime(p1, q1, 128, t)
+	if p1.Cmp(p2) != 0 || q1.Cmp(q2) != 0 {
+		t.Errorf(
+			"Unexpected safe prime for the same reader\nExpected: %v\nActual: %v",
+			p1,
+			p2,
+		)
+	}
+}
+
+func TestGenerateSafePrimes(t *testing.T) {
+	pairs, err := GenerateSafePrimes(3, 64, 2, 60*time.Second, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(pairs) != 3 {
+		t.Fatalf(
+			"Unexpected number of safe primes\nExpected: %v\nActual: %v",
+			3,
+			len(pairs),
+		)
+	}
+	for i, pair := range pairs {
+		IsSafePrime(pair.P, pair.Q, 64, t)
+		if i > 0 && pairs[i-1].P.Cmp(pair.P) >= 0 {
+			t.Errorf(
+				"safe primes are not distinct and sorted: %v, %v",
+				pairs[i-1].P,
+				pair.P,
+			)
+		}
+	}
+
+	if _, err := GenerateSafePrimes(0, 64, 2, 60*time.Second, rand.Reader); err == nil {
+		t.Error("expected an error for zero safe primes")
+	}
+}
+
+// Returns all the odd primes smaller than `limit`.
+func oddPrimesUpTo(limit int) []uint64 {
+	primes := make([]uint64, 0)
+	for i := 3; i < limit; i += 2 {
+		if big.NewInt(int64(i)).ProbablyPrime(20) {
+			primes = append(primes, uint64(i))
+		}
+	}
+	return primes
+}
+
+func TestGenerateSafePrimeWithSmallPrimes(t *testing.T) {
+	for _, bitLen := range []int{6, 64, 512} {
+		p, q, err := GenerateSafePrime(
+			bitLen,
+			1,
+			60*time.Second,
+			rand.Reader,
+			WithSmallPrimes(oddPrimesUpTo(1000)),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		IsSafePrime(p, q, bitLen, t)
+	}
+}
+
+func TestWithSmallPrimesRejectsNonOddPrimes(t *testing.T) {
+	var tests = map[string]struct {
+		primes        []uint64
+		expectedError error
+	}{
+		"empty list": {
+			primes:        []uint64{},
+			expectedError: errors.New("small primes list can not be empty"),
+		},
+		"two": {
+			primes:        []uint64{2, 3},
+			expectedError: errors.New("2 is not an odd prime"),
+		},
+		"composite": {
+			primes:        []uint64{3, 9},
+			expectedError: errors.New("9 is not an odd prime"),
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			_, _, err := GenerateSafePrime(
+				64,
+				1,
+				60*time.Second,
+				rand.Reader,
+				WithSmallPrimes(test.primes),
+			)
+			if !reflect.DeepEqual(test.expectedError, err) {
+				t.Errorf(
+					"Unexpected error\nExpected: %v\nActual: %v",
+					test.expectedError,
+					err,
+				)
+			}
+		})
+	}
+}
+
+func TestSmallPrimesSieveGroups(t *testing.T) {
+	if groups := len(defaultSieve.groups); groups != 1 {
+		t.Errorf("Unexpected number of default groups [%v]", groups)
+	}
+
+	sieve := newSmallPrimesSieve(oddPrimesUpTo(1000))
+	count := 0
+	for _, group := range sieve.groups {
+		product := big.NewInt(1)
+		for _, prime := range group.primes {
+			product.Mul(product, new(big.Int).SetUint64(prime))
+		}
+		if product.Cmp(group.product) != 0 || !product.IsUint64() {
+			t.Errorf("Unexpected group product [%v]", group.product)
+		}
+		count += len(group.primes)
+	}
+	if count != 167 {
+		t.Errorf("Unexpected number of primes in sieve [%v]", count)
+	}
+}
+
+func TestSatisfiesPocklington(t *testing.T) {
+	var tests = map[string]struct {
+		p        *big.Int
+		expected bool
+	}{
+		"safe prime 5": {
+			p:        big.NewInt(5),
+			expected: true,
+		},
+		"safe prime 23": {
+			p:        big.NewInt(23),
+			expected: true,
+		},
+		"safe prime 887": {
+			p:        big.NewInt(887),
+			expected: true,
+		},
+		"composite 15 = 2*7 + 1": {
+			p:        big.NewInt(15),
+			expected: false,
+		},
+		"composite 27 = 2*13 + 1": {
+			p:        big.NewInt(27),
+			expected: false,
+		},
+		"composite 35 = 2*17 + 1": {
+			p:        big.NewInt(35),
+			expected: false,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			actual := SatisfiesPocklington(test.p)
+			if actual != test.expected {
+				t.Errorf(
+					"Unexpected result\nExpected: %v\nActual: %v",
+					test.expected,
+					actual,
+				)
+			}
+		})
+	}
+}
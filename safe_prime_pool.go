@@ -0,0 +1,132 @@
+package paillier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+)
+
+// SafePrimePool pre-generates safe primes of the given bit length in the
+// background and hands them out on demand. It amortizes the latency of the
+// safe prime search across many key generations, for example in test suites
+// or services creating a lot of keys. See
+// `ThresholdKeyGenerator.UseSafePrimePool`.
+//
+// Every safe prime is handed out only once. If the pool is drained, `Get`
+// falls back to a synchronous search. If a background search fails, e.g.
+// because `random` returned an error, the pool stops filling and `Get`
+// returns that error once the primes already buffered are drained.
+//
+// The pool is safe for concurrent use.
+type SafePrimePool struct {
+	BitLength int
+
+	primes    chan safePrime
+	random    RandReader
+	done      chan struct{}
+	closeOnce sync.Once
+
+	errMutex sync.Mutex
+	err      error // the error which stopped filling the pool
+}
+
+// The timeout of a single safe prime search done by the pool.
+const safePrimePoolTimeout = 120 * time.Second
+
+// GetSafePrimePool constructs the pool buffering up to `size` safe primes of
+// `bitLength` bits and starts filling it in the background. `Close` should be
+// called once the pool is not needed anymore.
+func GetSafePrimePool(bitLength, size int, random RandReader) (*SafePrimePool, error) {
+	if bitLength < 6 {
+		return nil, errors.New("safe prime size must be at least 6 bits")
+	}
+	if bitLength > MaxSafePrimeBitLen {
+		return nil, fmt.Errorf(
+			"safe prime size must be at most %v bits",
+			MaxSafePrimeBitLen,
+		)
+	}
+	if size < 1 {
+		return nil, errors.New("pool size must be at least 1")
+	}
+
+	pool := &SafePrimePool{
+		BitLength: bitLength,
+		primes:    make(chan safePrime, size),
+		random:    random,
+		done:      make(chan struct{}),
+	}
+	go pool.fill()
+	return pool, nil
+}
+
+func (pool *SafePrimePool) fill() {
+	for {
+		// Once the pool is closed, no new search is started even if there is
+		// still room in the buffer.
+		select {
+		case <-pool.done:
+			return
+		default:
+		}
+
+		p, q, err := GenerateSafePrime(pool.BitLength, 1, safePrimePoolTimeout, pool.random)
+		if err != nil {
+			// Retrying at once would spin on a reader which keeps failing,
+			// so the pool stops and `Get` reports the error instead.
+			pool.errMutex.Lock()
+			pool.err = err
+			pool.errMutex.Unlock()
+			return
+		}
+
+		select {
+		case pool.primes <- safePrime{p: p, q: q}:
+		case <-pool.done:
+			return
+		}
+	}
+}
+
+// Get returns a safe prime `p = 2q + 1` from the pool, or searches for a new
+// one if the pool is empty.
+func (pool *SafePrimePool) Get() (*big.Int, *big.Int, error) {
+	return pool.GetContext(context.Background())
+}
+
+// GetContext works like `Get` but the search done when the pool is empty can
+// be aborted with `ctx`, in which case `ctx.Err()` is returned.
+func (pool *SafePrimePool) GetContext(ctx context.Context) (*big.Int, *big.Int, error) {
+	select {
+	case prime := <-pool.primes:
+		return prime.p, prime.q, nil
+	default:
+		if err := pool.fillError(); err != nil {
+			return nil, nil, err
+		}
+		return GenerateSafePrimeContext(
+			ctx, pool.BitLength, 1, safePrimePoolTimeout, pool.random,
+		)
+	}
+}
+
+// Returns the error which stopped filling the pool or `nil` if the pool is
+// still being filled.
+func (pool *SafePrimePool) fillError() error {
+	pool.errMutex.Lock()
+	defer pool.errMutex.Unlock()
+	return pool.err
+}
+
+// Close stops filling the pool in the background. No new search is started
+// after `Close`, but a search already in progress is completed before the
+// background routine exits. Primes already buffered can still be obtained with
+// `Get`.
+func (pool *SafePrimePool) Close() {
+	pool.closeOnce.Do(func() {
+		close(pool.done)
+	})
+}
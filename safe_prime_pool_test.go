@@ -0,0 +1,153 @@
+package paillier
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSafePrimePool(t *testing.T) {
+	pool, err := GetSafePrimePool(64, 4, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+
+	moduli := make([]*big.Int, 2)
+	for i := range moduli {
+		tkh, err := GetThresholdKeyGenerator(128, 3, 2, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := tkh.UseSafePrimePool(pool); err != nil {
+			t.Fatal(err)
+		}
+
+		tpks, err := tkh.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		message := big.NewInt(100)
+		c, err := tpks[0].Encrypt(message, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decrypted, err := ThresholdDecrypt(tpks, c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if decrypted.Cmp(message) != 0 {
+			t.Errorf(
+				"Unexpected decryption\nExpected: %v\nActual: %v",
+				message,
+				decrypted,
+			)
+		}
+
+		IsSafePrime(tkh.p, tkh.p1, 64, t)
+		IsSafePrime(tkh.q, tkh.q1, 64, t)
+		moduli[i] = tpks[0].N
+	}
+
+	if new(big.Int).GCD(nil, nil, moduli[0], moduli[1]).Cmp(ONE) != 0 {
+		t.Error("safe primes should not be reused across keys")
+	}
+}
+
+func TestSafePrimePoolFallsBackWhenDrained(t *testing.T) {
+	pool, err := GetSafePrimePool(32, 1, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool.Close()
+
+	for i := 0; i < 3; i++ {
+		p, q, err := pool.Get()
+		if err != nil {
+			t.Fatal(err)
+		}
+		IsSafePrime(p, q, 32, t)
+	}
+}
+
+type failingReader struct{}
+
+func (failingReader) Read(p []byte) (int, error) {
+	return 0, errors.New("reader failed")
+}
+
+func TestSafePrimePoolStopsOnFailingReader(t *testing.T) {
+	pool, err := GetSafePrimePool(64, 4, failingReader{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for pool.fillError() == nil {
+		if time.Now().After(deadline) {
+			t.Fatal("pool has not stopped filling")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	expectedError := errors.New("reader failed")
+	if _, _, err := pool.Get(); !reflect.DeepEqual(expectedError, err) {
+		t.Errorf(
+			"Unexpected error\nExpected: %v\nActual: %v",
+			expectedError,
+			err,
+		)
+	}
+}
+
+// Blocks every read until `release` is closed.
+type blockingReader struct {
+	release chan struct{}
+}
+
+func (r *blockingReader) Read(p []byte) (int, error) {
+	<-r.release
+	return rand.Read(p)
+}
+
+func TestSafePrimePoolStopsSearchingOnClose(t *testing.T) {
+	// After `Close`, filling could go on only by chance, so several pools are
+	// checked.
+	for i := 0; i < 20; i++ {
+		reader := &blockingReader{release: make(chan struct{})}
+		pool, err := GetSafePrimePool(16, 100, reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// At most the search blocked on the reader can be in progress.
+		pool.Close()
+		close(reader.release)
+
+		time.Sleep(50 * time.Millisecond)
+		if buffered := len(pool.primes); buffered > 1 {
+			t.Fatalf("pool has buffered %v safe primes after Close", buffered)
+		}
+	}
+}
+
+func TestUseSafePrimePoolBitLength(t *testing.T) {
+	pool, err := GetSafePrimePool(32, 1, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+
+	tkh, err := GetThresholdKeyGenerator(128, 3, 2, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tkh.UseSafePrimePool(pool); err == nil {
+		t.Error("expected an error for a pool of a wrong bit length")
+	}
+}
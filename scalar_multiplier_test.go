@@ -0,0 +1,106 @@
+package paillier
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+func TestScalarMultiplier(t *testing.T) {
+	privateKey := CreatePrivateKey(big.NewInt(463), big.NewInt(631))
+
+	cypher, err := privateKey.Encrypt(big.NewInt(12), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	multiplier := privateKey.NewScalarMultiplier(cypher)
+
+	randomScalar, err := rand.Int(rand.Reader, privateKey.N)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var tests = map[string]struct {
+		scalar *big.Int
+	}{
+		"zero": {
+			scalar: big.NewInt(0),
+		},
+		"one": {
+			scalar: big.NewInt(1),
+		},
+		"one digit": {
+			scalar: big.NewInt(15),
+		},
+		"many digits": {
+			scalar: big.NewInt(0x10f0a),
+		},
+		"random": {
+			scalar: randomScalar,
+		},
+		"N-1": {
+			scalar: new(big.Int).Sub(privateKey.N, ONE),
+		},
+		"longer than N": {
+			scalar: new(big.Int).Lsh(privateKey.N, 3),
+		},
+		"negative": {
+			scalar: big.NewInt(-3),
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			expected := privateKey.Mul(cypher, test.scalar)
+			actual := multiplier.Mul(test.scalar)
+
+			if expected.C.Cmp(actual.C) != 0 {
+				t.Errorf(
+					"Unexpected cypher\nExpected: %v\nActual: %v",
+					expected,
+					actual,
+				)
+			}
+		})
+	}
+}
+
+func getBenchmarkScalars(b *testing.B, privateKey *PrivateKey) []*big.Int {
+	scalars := make([]*big.Int, 1000)
+	for i := range scalars {
+		scalar, err := rand.Int(rand.Reader, privateKey.N)
+		if err != nil {
+			b.Fatal(err)
+		}
+		scalars[i] = scalar
+	}
+	return scalars
+}
+
+func BenchmarkMul1000Scalars(b *testing.B) {
+	privateKey := getBenchmarkPrivateKey(b)
+	cypher := getBenchmarkCypher(b, privateKey, 123)
+	scalars := getBenchmarkScalars(b, privateKey)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		for _, scalar := range scalars {
+			privateKey.Mul(cypher, scalar)
+		}
+	}
+}
+
+func BenchmarkScalarMultiplier1000Scalars(b *testing.B) {
+	privateKey := getBenchmarkPrivateKey(b)
+	cypher := getBenchmarkCypher(b, privateKey, 123)
+	scalars := getBenchmarkScalars(b, privateKey)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		// the precomputation is a part of the measured cost
+		multiplier := privateKey.NewScalarMultiplier(cypher)
+		for _, scalar := range scalars {
+			multiplier.Mul(scalar)
+		}
+	}
+}
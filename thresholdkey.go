@@ -1,10 +1,13 @@
 package paillier
 
 import (
+	"bytes"
+	"context"
 	"crypto/rand"
 	"crypto/sha256"
+	"crypto/subtle"
 	"errors"
-	"io"
+	"fmt"
 	"math/big"
 )
 
@@ -31,12 +34,35 @@ type ThresholdPublicKey struct {
 	Vi                             []*big.Int // needed for ZKP
 }
 
+// Equal compares the threshold public key with `other` by value, including
+// all the verification keys `Vi`.
+func (tk *ThresholdPublicKey) Equal(other *ThresholdPublicKey) bool {
+	if other == nil {
+		return false
+	}
+	if !tk.PublicKey.Equal(&other.PublicKey) ||
+		tk.V.Cmp(other.V) != 0 ||
+		tk.Threshold != other.Threshold ||
+		tk.TotalNumberOfDecryptionServers != other.TotalNumberOfDecryptionServers ||
+		len(tk.Vi) != len(other.Vi) {
+		return false
+	}
+	for i, vi := range tk.Vi {
+		if vi.Cmp(other.Vi[i]) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
 // Returns the value of [(4*delta^2)]^-1  mod n.
 // It is a constant value for the given `ThresholdKey` and is used in the last
 // step of share combining.
-func (tk *ThresholdPublicKey) combineSharesConstant() *big.Int {
+// An error is returned if it is not invertible modulo n, which happens only
+// for keys with too many decryption servers for their N.
+func (tk *ThresholdPublicKey) combineSharesConstant() (*big.Int, error) {
 	tmp := new(big.Int).Mul(FOUR, new(big.Int).Mul(tk.delta(), tk.delta()))
-	return (&big.Int{}).ModInverse(tmp, tk.N)
+	return mustInverse(tmp, tk.N)
 }
 
 // Returns the factorial of the number of `TotalNumberOfDecryptionServers`.
@@ -46,9 +72,16 @@ func (tk *ThresholdPublicKey) delta() *big.Int {
 }
 
 // Checks if the number of received, unique shares is less than the
-// required threshold.
+// required threshold. At least one share is always required, even if the key
+// is misconfigured with a threshold lower than one.
 // This method does not execute ZKP on received shares.
 func (tk *ThresholdPublicKey) verifyPartialDecryptions(shares []*PartialDecryption) error {
+	if tk.Threshold < 1 {
+		return errors.New("Threshold must be at least 1")
+	}
+	if len(shares) == 0 {
+		return errors.New("no partial decryptions supplied")
+	}
 	if len(shares) < tk.Threshold {
 		return errors.New("Threshold not meet")
 	}
@@ -62,6 +95,35 @@ func (tk *ThresholdPublicKey) verifyPartialDecryptions(shares []*PartialDecrypti
 	return nil
 }
 
+// MissingShareIds returns, in ascending order, the ids of the decryption
+// servers from 1 to `TotalNumberOfDecryptionServers` which have not produced
+// any of the `present` partial decryptions. It lets the coordinator of
+// a decryption find out which servers to wait for.
+func (tk *ThresholdPublicKey) MissingShareIds(present []*PartialDecryption) []int {
+	ids := make(map[int]bool)
+	for _, share := range present {
+		ids[share.Id] = true
+	}
+
+	missing := make([]int, 0)
+	for id := 1; id <= tk.TotalNumberOfDecryptionServers; id++ {
+		if !ids[id] {
+			missing = append(missing, id)
+		}
+	}
+	return missing
+}
+
+// HasThreshold reports whether the `present` partial decryptions come from
+// at least `Threshold` distinct decryption servers with ids from 1 to
+// `TotalNumberOfDecryptionServers`, that is whether there are enough of them
+// to be combined. Zero knowledge proofs are not verified.
+func (tk *ThresholdPublicKey) HasThreshold(present []*PartialDecryption) bool {
+	missing := len(tk.MissingShareIds(present))
+	return tk.Threshold >= 1 &&
+		tk.TotalNumberOfDecryptionServers-missing >= tk.Threshold
+}
+
 func (tk *ThresholdPublicKey) updateLambda(share1, share2 *PartialDecryption, lambda *big.Int) *big.Int {
 	num := new(big.Int).Mul(lambda, big.NewInt(int64(-share2.Id)))
 	denom := big.NewInt(int64(share1.Id - share2.Id))
@@ -90,33 +152,32 @@ func (tk *ThresholdPublicKey) computeLambda(share *PartialDecryption, shares []*
 // following property of modulo:
 // (AB) mod C = (A mod C * B mod C) mod C
 // Note, we need to combine coefficients into single c'.
-func (tk *ThresholdPublicKey) updateCprime(cprime, lambda *big.Int, share *PartialDecryption) *big.Int {
-	twoLambda := new(big.Int).Mul(TWO, lambda)
-	ret := tk.exp(share.Decryption, twoLambda, tk.GetNSquare())
-	ret = new(big.Int).Mul(cprime, ret)
-	return new(big.Int).Mod(ret, tk.GetNSquare())
-}
-
-// We use `exp` from `updateCprime` to raise decryption share to the power of lambda
-// parameter. Since lambda can be a negative number and we do discrete math here,
-// we need to apply multiplicative inverse modulo in this case.
 //
-// For instance, for b = -18:
-// b^{−18} = (b^−1)^18, where b^{−1} is the multiplicative inverse modulo c.
-func (tk *ThresholdPublicKey) exp(a, b, c *big.Int) *big.Int {
-	if b.Cmp(ZERO) == -1 { // b < 0 ?
-		ret := new(big.Int).Exp(a, new(big.Int).Neg(b), c)
-		return new(big.Int).ModInverse(ret, c)
+// Since lambda can be a negative number, the decryption share is raised to the
+// power of lambda with `ModExp` which applies the multiplicative inverse modulo
+// in this case.
+//
+// `nSquare` is N^2, computed once by the caller for all the shares.
+func (tk *ThresholdPublicKey) updateCprime(cprime, lambda *big.Int, share *PartialDecryption, nSquare *big.Int) (*big.Int, error) {
+	twoLambda := new(big.Int).Mul(TWO, lambda)
+	ret, err := ModExp(share.Decryption, twoLambda, nSquare)
+	if err != nil {
+		return nil, err
 	}
-	return new(big.Int).Exp(a, b, c)
+	ret = new(big.Int).Mul(cprime, ret)
+	return new(big.Int).Mod(ret, nSquare), nil
 }
 
 // Executes the last step of message decryption. Takes `cprime` value computed
 // from valid shares provided by decryption servers and multiplies this value
 // by `combineSharesContant` which is specific to the given public `ThresholdKey`.
-func (tk *ThresholdPublicKey) computeDecryption(cprime *big.Int) *big.Int {
+func (tk *ThresholdPublicKey) computeDecryption(cprime *big.Int) (*big.Int, error) {
+	constant, err := tk.combineSharesConstant()
+	if err != nil {
+		return nil, err
+	}
 	l := L(cprime, tk.N)
-	return new(big.Int).Mod(new(big.Int).Mul(tk.combineSharesConstant(), l), tk.N)
+	return new(big.Int).Mod(new(big.Int).Mul(constant, l), tk.N), nil
 }
 
 // Combines partial decryptions provided by decryption servers and returns
@@ -128,20 +189,99 @@ func (tk *ThresholdPublicKey) CombinePartialDecryptions(shares []*PartialDecrypt
 		return nil, err
 	}
 
+	nSquare := tk.GetNSquare()
 	cprime := ONE
 	for _, share := range shares {
 		lambda := tk.computeLambda(share, shares)
-		cprime = tk.updateCprime(cprime, lambda, share)
+		var err error
+		if cprime, err = tk.updateCprime(cprime, lambda, share, nSquare); err != nil {
+			return nil, err
+		}
 	}
 
-	return tk.computeDecryption(cprime), nil
+	return tk.computeDecryption(cprime)
+}
+
+// IsZeroShares combines partial decryptions just like
+// `CombinePartialDecryptions` and reports whether they decrypt to zero.
+// It is meant for comparison protocols in which decryption servers blind an
+// encrypted difference with a random non-zero factor and jointly check only
+// whether it is zero.
+//
+// The comparison of the combined message with zero is done in constant time
+// with respect to its value, but this is a best effort only: the combiner
+// computes the whole message on its way and learns it. The blinding of the
+// encrypted value is what keeps it secret, not this function.
+// This function does not verify zero knowledge proofs.
+func (tk *ThresholdPublicKey) IsZeroShares(shares []*PartialDecryption) (bool, error) {
+	message, err := tk.CombinePartialDecryptions(shares)
+	if err != nil {
+		return false, err
+	}
+
+	size := (tk.N.BitLen() + 7) / 8
+	messageBytes := message.FillBytes(make([]byte, size))
+	return subtle.ConstantTimeCompare(messageBytes, make([]byte, size)) == 1, nil
+}
+
+// PrepareEqualityToConstant returns a cypher encoding r * (m - k) mod N,
+// where `m` is the plaintext of `cypher`, `k` is a public constant in the
+// plaintext space and `r` is a random blinding factor invertible modulo N.
+// The cypher is rerandomized, so it can't be linked to `cypher`.
+//
+// Since `r` is invertible, r * (m - k) = 0 mod N if and only if m = k. The
+// threshold decryption of the returned cypher, e.g. with `IsZeroShares`,
+// reveals whether `m` equals `k` and nothing else: for m != k, the combiner
+// learns r * (m - k), which is a uniformly random non-zero value as long as
+// `r` is unknown to it. Whoever chooses `r` could learn `m` from it, so in
+// a protocol without a trusted party every server should blind the cypher
+// in turn, e.g. by multiplying it by its own random scalar.
+func (tk *ThresholdPublicKey) PrepareEqualityToConstant(cypher *Cypher, k *big.Int, random RandReader) (*Cypher, error) {
+	difference, err := tk.SubConstant(cypher, k)
+	if err != nil {
+		return nil, err
+	}
+	blinding, err := GetRandomNumberInMultiplicativeGroup(tk.N, random)
+	if err != nil {
+		return nil, err
+	}
+	zero, _, err := tk.EncryptZero(random)
+	if err != nil {
+		return nil, err
+	}
+	return tk.Add(tk.Mul(difference, blinding), zero), nil
+}
+
+// CombineCostEstimate returns the number of modular exponentiations modulo N^2
+// `CombinePartialDecryptions` performs when combining exactly `Threshold`
+// partial decryptions: one per share. Every additional share supplied above
+// the threshold costs one more exponentiation. The remaining work, computing
+// Lagrange coefficients and a single inverse modulo N, is negligible.
+//
+// It is an informational helper meant for capacity planning when choosing
+// the threshold.
+func (tk *ThresholdPublicKey) CombineCostEstimate() (exponentiations int) {
+	return tk.Threshold
 }
 
 // Combines partial decryptions provided by decryption servers and returns
 // full decrypted message.
 // Function verifies zero knowledge proofs and filters out all shares that failed
 // verification.
+// All shares must have been produced with this key. Shares of another key set
+// would pass the verification against their own key but combined with the
+// other shares they would decrypt to garbage, so an error is returned if `N`
+// of any share key differs from `N` of this key.
 func (tk *ThresholdPublicKey) CombinePartialDecryptionsZKP(shares []*PartialDecryptionZKP) (*big.Int, error) {
+	for _, share := range shares {
+		if share.Key == nil || share.Key.N == nil || share.Key.N.Cmp(tk.N) != 0 {
+			return nil, fmt.Errorf(
+				"partial decryption of server %v has been produced with another key",
+				share.Id,
+			)
+		}
+	}
+
 	ret := make([]*PartialDecryption, 0)
 	for _, share := range shares {
 		if share.Verify() {
@@ -151,6 +291,54 @@ func (tk *ThresholdPublicKey) CombinePartialDecryptionsZKP(shares []*PartialDecr
 	return tk.CombinePartialDecryptions(ret)
 }
 
+// VerificationProductCheck verifies the zero-knowledge proofs of all the
+// partial decryptions of a single decryption round, as a single entry point
+// for external verifiers. It is not a batch verification: the proofs are
+// checked one by one, each against `V` and `Vi` of this key, not against the
+// key recorded in the share, so it ties the partial decryption of server `i`
+// to its public verification key v_i = v^(delta * s_i).
+//
+// An error is returned if any share is nil or incomplete, or if the shares
+// don't decrypt the same cypher. Otherwise, the result of all the checks is
+// aggregated into a single error listing the ids of all the servers whose
+// proof failed, `nil` if all of them passed.
+func (tk *ThresholdPublicKey) VerificationProductCheck(shares []*PartialDecryptionZKP) error {
+	if len(shares) == 0 {
+		return errors.New("no partial decryptions supplied")
+	}
+	for i, share := range shares {
+		if share == nil {
+			return fmt.Errorf("partial decryption %v is nil", i)
+		}
+		if !share.isComplete() {
+			return fmt.Errorf(
+				"partial decryption of server %v is incomplete",
+				share.Id,
+			)
+		}
+	}
+
+	failed := make([]int, 0)
+	for _, share := range shares {
+		if share.C.Cmp(shares[0].C) != 0 {
+			return errors.New("partial decryptions are not of the same cypher")
+		}
+
+		checked := *share
+		checked.Key = tk
+		if !checked.Verify() {
+			failed = append(failed, share.Id)
+		}
+	}
+	if len(failed) != 0 {
+		return fmt.Errorf(
+			"partial decryptions of servers %v failed verification",
+			failed,
+		)
+	}
+	return nil
+}
+
 // Verifies if the decryption of `encryptedMessage` has been done properly.
 // It verifies all the zero-knoledge proofs, the value of the encrypted
 // and decrypted message. The method returns `nil` if everything is fine.
@@ -182,6 +370,8 @@ type ThresholdPrivateKey struct {
 }
 
 // Decrypts the cypher text and returns the partial decryption
+// c^(2 delta s_i) mod N^2, which is the form expected by
+// `CombinePartialDecryptions`.
 func (tpk *ThresholdPrivateKey) Decrypt(c *big.Int) *PartialDecryption {
 	ret := new(PartialDecryption)
 	ret.Id = tpk.Id
@@ -191,6 +381,15 @@ func (tpk *ThresholdPrivateKey) Decrypt(c *big.Int) *PartialDecryption {
 	return ret
 }
 
+// RawPartialDecrypt returns c^s_i mod N^2 where s_i is the secret share of
+// this decryption server. It is the building block of `Decrypt` without the
+// 2*delta scaling, for custom combiners of non-standard schemes. The result
+// can not be combined with `CombinePartialDecryptions`, which expects the
+// 2*delta-scaled form returned by `Decrypt`.
+func (tpk *ThresholdPrivateKey) RawPartialDecrypt(c *big.Int) *big.Int {
+	return new(big.Int).Exp(c, tpk.Share, tpk.GetNSquare())
+}
+
 func (tpk *ThresholdPrivateKey) copyVi() []*big.Int {
 	ret := make([]*big.Int, len(tpk.Vi))
 	for i, vi := range tpk.Vi {
@@ -215,8 +414,9 @@ func (tpk *ThresholdPrivateKey) computeZ(r, e *big.Int) *big.Int {
 	return new(big.Int).Add(r, tmp)
 }
 
-func (tpk *ThresholdPrivateKey) computeHash(a, b, c4, ci2 *big.Int) *big.Int {
+func (tpk *ThresholdPrivateKey) computeHash(label []byte, a, b, c4, ci2 *big.Int) *big.Int {
 	hash := sha256.New()
+	hash.Write(label)
 	hash.Write(a.Bytes())
 	hash.Write(b.Bytes())
 	hash.Write(c4.Bytes())
@@ -224,11 +424,41 @@ func (tpk *ThresholdPrivateKey) computeHash(a, b, c4, ci2 *big.Int) *big.Int {
 	return new(big.Int).SetBytes(hash.Sum([]byte{}))
 }
 
-func (tpk *ThresholdPrivateKey) DecryptAndProduceZNP(c *big.Int, random io.Reader) (*PartialDecryptionZKP, error) {
+// DecryptAndProduceZKP decrypts the cypher text and returns the partial
+// decryption together with a zero-knowledge proof that the decryption has been
+// done with the secret share of this key. See `PartialDecryptionZKP`.
+func (tpk *ThresholdPrivateKey) DecryptAndProduceZKP(c *big.Int, random RandReader) (*PartialDecryptionZKP, error) {
+	return tpk.DecryptAndProduceZKPWithLabel(c, nil, random)
+}
+
+// DecryptAndProduceZKPWithLabel works like `DecryptAndProduceZKP` but binds
+// the proof to the `label`, for instance the identifier of the decryption
+// request. The label is mixed into the Fiat-Shamir hash and stored in the
+// returned proof so that an auditor can tie the proof to the request with
+// `PartialDecryptionZKP.VerifyWithLabel`.
+func (tpk *ThresholdPrivateKey) DecryptAndProduceZKPWithLabel(c *big.Int, label []byte, random RandReader) (*PartialDecryptionZKP, error) {
+	return tpk.decryptAndProduceZKP(tpk.getThresholdKey(), c, label, random)
+}
+
+// DecryptAndProduceZKPSharedKey works like `DecryptAndProduceZKPWithLabel` but
+// the returned proof references the public part of this key instead of
+// a deep copy of it. It saves copying `N`, `V` and all `Vi` for every proof
+// which matters for servers producing a lot of proofs with large keys.
+//
+// The proof aliases the key: modifying `Key` of the proof modifies this key
+// and all the other proofs produced with this function, and vice versa.
+// Proofs that are mutated or outlive changes of the key should be produced
+// with `DecryptAndProduceZKPWithLabel` instead.
+func (tpk *ThresholdPrivateKey) DecryptAndProduceZKPSharedKey(c *big.Int, label []byte, random RandReader) (*PartialDecryptionZKP, error) {
+	return tpk.decryptAndProduceZKP(&tpk.ThresholdPublicKey, c, label, random)
+}
+
+func (tpk *ThresholdPrivateKey) decryptAndProduceZKP(key *ThresholdPublicKey, c *big.Int, label []byte, random RandReader) (*PartialDecryptionZKP, error) {
 	pd := new(PartialDecryptionZKP)
-	pd.Key = tpk.getThresholdKey()
+	pd.Key = key
 	pd.C = c
 	pd.Id = tpk.Id
+	pd.Label = label
 	pd.Decryption = tpk.Decrypt(c).Decryption
 
 	// choose random number
@@ -246,16 +476,35 @@ func (tpk *ThresholdPrivateKey) DecryptAndProduceZNP(c *big.Int, random io.Reade
 	// compute hash
 	ci2 := new(big.Int).Exp(pd.Decryption, big.NewInt(2), nil)
 
-	pd.E = tpk.computeHash(a, b, c4, ci2)
+	pd.E = tpk.computeHash(pd.Label, a, b, c4, ci2)
 
 	pd.Z = tpk.computeZ(r, pd.E)
 
 	return pd, nil
 }
 
+// DecryptAndProduceZNP is the former name of `DecryptAndProduceZKP`.
+//
+// Deprecated: use DecryptAndProduceZKP instead.
+func (tpk *ThresholdPrivateKey) DecryptAndProduceZNP(c *big.Int, random RandReader) (*PartialDecryptionZKP, error) {
+	return tpk.DecryptAndProduceZKP(c, random)
+}
+
 // Verifies if the partial decryption key is well formed.  If well formed,
 // the method return nil else an explicative error is returned.
-func (tpk *ThresholdPrivateKey) Validate(random io.Reader) error {
+func (tpk *ThresholdPrivateKey) Validate(random RandReader) error {
+	return tpk.ValidateContext(context.Background(), random)
+}
+
+// ValidateContext works like `Validate` but the validation, which can be
+// slow for long keys, is aborted when `ctx` is done and `ctx.Err()` is
+// returned. The context is checked between the encryption, the production
+// of the ZKP and its verification; a single step in progress is not
+// interrupted.
+func (tpk *ThresholdPrivateKey) ValidateContext(ctx context.Context, random RandReader) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	m, err := rand.Int(random, tpk.N)
 	if err != nil {
 		return err
@@ -264,16 +513,110 @@ func (tpk *ThresholdPrivateKey) Validate(random io.Reader) error {
 	if err != nil {
 		return err
 	}
-	proof, err := tpk.DecryptAndProduceZNP(c.C, random)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	proof, err := tpk.DecryptAndProduceZKP(c.C, random)
 	if err != nil {
 		return err
 	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	if !proof.Verify() {
 		return errors.New("invalid share.")
 	}
 	return nil
 }
 
+// VerifyOwnVi checks that the verification key of this decryption server,
+// `Vi[Id-1]`, is equal v^(delta s_i) mod N^2 where v is `V` and s_i is the
+// secret `Share`. It lets the server detect a malicious or buggy dealer
+// before participating in decryptions.
+func (tpk *ThresholdPrivateKey) VerifyOwnVi() bool {
+	if tpk.Id < 1 || tpk.Id > len(tpk.Vi) {
+		return false
+	}
+	exp := new(big.Int).Mul(tpk.delta(), tpk.Share)
+	vi := new(big.Int).Exp(tpk.V, exp, tpk.GetNSquare())
+	return vi.Cmp(tpk.Vi[tpk.Id-1]) == 0
+}
+
+// ReconstructThresholdPublicKey returns the threshold public key embedded in
+// all the `keys`. An error is returned if the keys do not agree on the public
+// key, e.g. when decryption servers were initialized from inconsistent key
+// material.
+func ReconstructThresholdPublicKey(keys []*ThresholdPrivateKey) (*ThresholdPublicKey, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("no threshold keys supplied")
+	}
+	for _, key := range keys[1:] {
+		if !key.ThresholdPublicKey.Equal(&keys[0].ThresholdPublicKey) {
+			return nil, fmt.Errorf(
+				"threshold public key of server %v differs from server %v",
+				key.Id,
+				keys[0].Id,
+			)
+		}
+	}
+	return keys[0].getThresholdKey(), nil
+}
+
+// ThresholdDecrypt decrypts `cypher` with the first `Threshold` keys from
+// `keys` and combines their partial decryptions in one step. It is meant for
+// tests and simple deployments where all the keys live in the same process.
+// Zero-knowledge proofs are not produced nor verified.
+func ThresholdDecrypt(keys []*ThresholdPrivateKey, cypher *Cypher) (*big.Int, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("no threshold keys supplied")
+	}
+	threshold := keys[0].Threshold
+	if threshold < 1 {
+		return nil, errors.New("Threshold must be at least 1")
+	}
+	if len(keys) < threshold {
+		return nil, errors.New("Threshold not meet")
+	}
+
+	shares := make([]*PartialDecryption, threshold)
+	for i := 0; i < threshold; i++ {
+		shares[i] = keys[i].Decrypt(cypher.C)
+	}
+	return keys[0].CombinePartialDecryptions(shares)
+}
+
+// ThresholdKeyInfo describes a `ThresholdPrivateKey` with non-secret values
+// only, so it can be safely logged or displayed. `Fingerprint` is the
+// fingerprint of the public key, see `PublicKey.Fingerprint`.
+type ThresholdKeyInfo struct {
+	Id                             int
+	Threshold                      int
+	TotalNumberOfDecryptionServers int
+	BitLength                      int
+	Fingerprint                    []byte
+}
+
+// Info returns the non-secret metadata of the key. The secret `Share` is
+// never a part of it.
+func (tpk *ThresholdPrivateKey) Info() ThresholdKeyInfo {
+	return ThresholdKeyInfo{
+		Id:                             tpk.Id,
+		Threshold:                      tpk.Threshold,
+		TotalNumberOfDecryptionServers: tpk.TotalNumberOfDecryptionServers,
+		BitLength:                      tpk.N.BitLen(),
+		Fingerprint:                    tpk.Fingerprint(),
+	}
+}
+
+// Zeroize overwrites the secret `Share` of the key with zeros. It should be
+// called once the key is not needed anymore.
+//
+// This is a best-effort operation: the Go garbage collector and big.Int
+// internals may have left copies of the value elsewhere in memory.
+func (tpk *ThresholdPrivateKey) Zeroize() {
+	zeroize(tpk.Share)
+}
+
 type PartialDecryption struct {
 	Id         int
 	Decryption *big.Int
@@ -335,39 +678,87 @@ type PartialDecryptionZKP struct {
 	E   *big.Int            // the challenge
 	Z   *big.Int            // the value needed to check to verify the decryption
 	C   *big.Int            // the input cypher text
+
+	Label []byte // optional label the proof is bound to
 }
 
-func (pd *PartialDecryptionZKP) verifyPart1() *big.Int {
+func (pd *PartialDecryptionZKP) verifyPart1() (*big.Int, error) {
 	c4 := new(big.Int).Exp(pd.C, FOUR, nil)                  // c^4
 	decryption2 := new(big.Int).Exp(pd.Decryption, TWO, nil) // c_i^2
 
-	a1 := new(big.Int).Exp(c4, pd.Z, pd.Key.GetNSquare())          // (c^4)^Z
-	a2 := new(big.Int).Exp(decryption2, pd.E, pd.Key.GetNSquare()) // (c_i^2)^E
-	a2 = new(big.Int).ModInverse(a2, pd.Key.GetNSquare())
+	a1 := new(big.Int).Exp(c4, pd.Z, pd.Key.GetNSquare())                       // (c^4)^Z
+	a2, err := ModExp(decryption2, new(big.Int).Neg(pd.E), pd.Key.GetNSquare()) // [(c_i^2)^E]^-1
+	if err != nil {
+		return nil, err
+	}
 	a := new(big.Int).Mod(new(big.Int).Mul(a1, a2), pd.Key.GetNSquare())
-	return a
+	return a, nil
 }
 
-func (pd *PartialDecryptionZKP) verifyPart2() *big.Int {
-	vi := pd.Key.Vi[pd.Id-1]                                    // servers are indexed from 1
-	b1 := new(big.Int).Exp(pd.Key.V, pd.Z, pd.Key.GetNSquare()) // V^Z
-	b2 := new(big.Int).Exp(vi, pd.E, pd.Key.GetNSquare())       // (v_i)^E
-	b2 = new(big.Int).ModInverse(b2, pd.Key.GetNSquare())
+func (pd *PartialDecryptionZKP) verifyPart2() (*big.Int, error) {
+	if pd.Id < 1 || pd.Id > len(pd.Key.Vi) {
+		return nil, fmt.Errorf(
+			"server id %v is out of allowed range [1, %v]",
+			pd.Id,
+			len(pd.Key.Vi),
+		)
+	}
+	vi := pd.Key.Vi[pd.Id-1]                                           // servers are indexed from 1
+	b1 := new(big.Int).Exp(pd.Key.V, pd.Z, pd.Key.GetNSquare())        // V^Z
+	b2, err := ModExp(vi, new(big.Int).Neg(pd.E), pd.Key.GetNSquare()) // [(v_i)^E]^-1
+	if err != nil {
+		return nil, err
+	}
 	b := new(big.Int).Mod(new(big.Int).Mul(b1, b2), pd.Key.GetNSquare())
-	return b
+	return b, nil
+}
+
+// Transcript returns the values hashed by the verifier to recompute the
+// challenge E: `a` and `b` recomputed from the proof, `c^4` and `c_i^2`. The
+// proof is valid if SHA-256 of the `Label` followed by the bytes of those
+// values equals `E`, which lets external tools re-hash and compare the
+// transcript on their own. `a` and `b` are nil if they can not be recomputed.
+func (pd *PartialDecryptionZKP) Transcript() (a, b, c4, ci2 *big.Int) {
+	c4 = new(big.Int).Exp(pd.C, FOUR, nil)
+	ci2 = new(big.Int).Exp(pd.Decryption, TWO, nil)
+
+	a, err := pd.verifyPart1()
+	if err != nil {
+		return nil, nil, c4, ci2
+	}
+	b, err = pd.verifyPart2()
+	if err != nil {
+		return nil, nil, c4, ci2
+	}
+	return a, b, c4, ci2
+}
+
+// Checks that none of the values of the proof is nil, so that it can be
+// verified without a nil dereference. The key is not checked: verifiers
+// replace it with their own.
+func (pd *PartialDecryptionZKP) isComplete() bool {
+	return pd.C != nil && pd.Decryption != nil && pd.E != nil && pd.Z != nil
 }
 
 func (pd *PartialDecryptionZKP) Verify() bool {
-	a := pd.verifyPart1()
-	b := pd.verifyPart2()
+	a, b, c4, ci2 := pd.Transcript()
+	if a == nil || b == nil {
+		return false
+	}
 	hash := sha256.New()
+	hash.Write(pd.Label)
 	hash.Write(a.Bytes())
 	hash.Write(b.Bytes())
-	c4 := new(big.Int).Exp(pd.C, FOUR, nil)
 	hash.Write(c4.Bytes())
-	ci2 := new(big.Int).Exp(pd.Decryption, TWO, nil)
 	hash.Write(ci2.Bytes())
 
 	expectedE := new(big.Int).SetBytes(hash.Sum([]byte{}))
 	return pd.E.Cmp(expectedE) == 0
 }
+
+// VerifyWithLabel verifies the proof like `Verify` and additionally checks
+// that the proof has been bound to the expected `label` when produced with
+// `DecryptAndProduceZKPWithLabel`.
+func (pd *PartialDecryptionZKP) VerifyWithLabel(label []byte) bool {
+	return bytes.Equal(pd.Label, label) && pd.Verify()
+}
@@ -1,9 +1,10 @@
 package paillier
 
 import (
+	"context"
 	"crypto/rand"
 	"errors"
-	"io"
+	"fmt"
 	"math/big"
 	"time"
 )
@@ -23,7 +24,7 @@ type ThresholdKeyGenerator struct {
 	PublicKeyBitLength             int
 	TotalNumberOfDecryptionServers int
 	Threshold                      int
-	random                         io.Reader
+	random                         RandReader
 
 	p *big.Int // p is prime of `PublicKeyBitLength/2` bits and `p = 2*p1 + 1`
 	q *big.Int // q is prime of `PublicKeyBitLength/2` bits and `q = 2*q1 + 1`
@@ -44,19 +45,69 @@ type ThresholdKeyGenerator struct {
 
 	// The polynomial coefficients to hide a secret. See Shamir.
 	polynomialCoefficients []*big.Int
+
+	// Set if p, p1, q and q1 were supplied and should not be generated.
+	fixedPrimes bool
+
+	// Optional source of pre-generated safe primes.
+	pool *SafePrimePool
+
+	// Optional generator of QR in Z_{n^2} used instead of a random v.
+	fixedV *big.Int
+
+	// Parameters of the safe prime search, see `GenerateSafePrime`.
+	safePrimeConcurrencyLevel int
+	safePrimeTimeout          time.Duration
 }
 
+// DefaultSafePrimeConcurrencyLevel is the number of routines used by
+// `ThresholdKeyGenerator` to search for each safe prime unless configured
+// otherwise with `WithSafePrimeConcurrencyLevel`.
+const DefaultSafePrimeConcurrencyLevel = 4
+
+// DefaultSafePrimeTimeout is the time after which `ThresholdKeyGenerator`
+// gives up the search for safe primes unless configured otherwise with
+// `WithSafePrimeTimeout`. It is a single budget for the whole search done by
+// `Generate`: both `p` and `q` and any retry must be found within it.
+const DefaultSafePrimeTimeout = 120 * time.Second
+
+// MinPublicKeyBitLength is the minimum public key `N` bit length accepted by
+// `GetThresholdKeyGenerator`. We need to find two n/2-bit safe primes, P and Q
+// which are not equal. This is not possible for n<18.
+//
+// Keys of such a length are only suitable for tests. See
+// `RecommendedMinPublicKeyBitLength` for production use.
+const MinPublicKeyBitLength = 18
+
+// RecommendedMinPublicKeyBitLength is the minimum public key `N` bit length
+// recommended for production use and enforced by
+// `GetThresholdKeyGeneratorStrict`.
+const RecommendedMinPublicKeyBitLength = 2048
+
+// MaxTotalNumberOfDecryptionServers is the upper bound on the number of
+// decryption servers accepted by `GetThresholdKeyGenerator`.
+//
+// Share combining works with `delta = l!` where `l` is the total number of
+// decryption servers. `delta` grows very fast (1000! is already about 8530 bits
+// long) and so does the cost of combining partial decryptions. The limit can be
+// raised by the caller if a larger setup is really needed.
+var MaxTotalNumberOfDecryptionServers = 1000
+
 // GetThresholdKeyGenerator is a preferable way to construct the
 // ThresholdKeyGenerator.
 // Due to the various properties that must be met for the threshold key to be
-// considered valid, the minimum public key `N` bit length is 18 bits and the
-// public key bit length should be an even number.
+// considered valid, the minimum public key `N` bit length is
+// `MinPublicKeyBitLength` and the public key bit length should be an even
+// number.
+// The total number of decryption servers can not exceed
+// `MaxTotalNumberOfDecryptionServers` and must be smaller than the primes
+// `p1` and `q1`, that is than 2^(publicKeyBitLength/2 - 2).
 // The plaintext space for the key will be `Z_N`.
 func GetThresholdKeyGenerator(
 	publicKeyBitLength int,
 	totalNumberOfDecryptionServers int,
 	threshold int,
-	random io.Reader,
+	random RandReader,
 ) (*ThresholdKeyGenerator, error) {
 	if publicKeyBitLength%2 == 1 {
 		// For an odd n-bit number, we can't find two n/2-bit numbers with two
@@ -64,10 +115,31 @@ func GetThresholdKeyGenerator(
 		// number.
 		return nil, errors.New("Public key bit length must be an even number")
 	}
-	if publicKeyBitLength < 18 {
-		// We need to find two n/2-bit safe primes, P and Q which are not equal.
-		// This is not possible for n<18.
-		return nil, errors.New("Public key bit length must be at least 18 bits")
+	if publicKeyBitLength/2 < 6 {
+		// `GenerateSafePrime` can not find safe primes shorter than 6 bits.
+		return nil, fmt.Errorf(
+			"Public key bit length of %v bits is too small to be split into two safe primes of at least 6 bits",
+			publicKeyBitLength,
+		)
+	}
+	if publicKeyBitLength < MinPublicKeyBitLength {
+		return nil, fmt.Errorf(
+			"Public key bit length must be at least %v bits",
+			MinPublicKeyBitLength,
+		)
+	}
+	if err := checkTotalNumberOfDecryptionServers(
+		totalNumberOfDecryptionServers,
+	); err != nil {
+		return nil, err
+	}
+	// p1 and q1 are not known yet but they are at least
+	// 2^(publicKeyBitLength/2 - 2).
+	if err := checkDecryptionServersBelowPrimes(
+		totalNumberOfDecryptionServers,
+		new(big.Int).Lsh(ONE, uint(publicKeyBitLength/2-2)),
+	); err != nil {
+		return nil, err
 	}
 
 	return &ThresholdKeyGenerator{
@@ -75,26 +147,169 @@ func GetThresholdKeyGenerator(
 		TotalNumberOfDecryptionServers: totalNumberOfDecryptionServers,
 		Threshold:                      threshold,
 		random:                         random,
+		safePrimeConcurrencyLevel:      DefaultSafePrimeConcurrencyLevel,
+		safePrimeTimeout:               DefaultSafePrimeTimeout,
 	}, nil
 }
 
-func (tkg *ThresholdKeyGenerator) generateSafePrimes() (*big.Int, *big.Int, error) {
-	concurrencyLevel := 4
-	timeout := 120 * time.Second
+func checkTotalNumberOfDecryptionServers(totalNumberOfDecryptionServers int) error {
+	if totalNumberOfDecryptionServers > MaxTotalNumberOfDecryptionServers {
+		return fmt.Errorf(
+			"Total number of decryption servers must be at most %v",
+			MaxTotalNumberOfDecryptionServers,
+		)
+	}
+	return nil
+}
+
+// delta = l! must be invertible modulo N, so the number of decryption servers
+// l must be smaller than the primes p1 and q1, the smaller of which is
+// `minPrime`.
+func checkDecryptionServersBelowPrimes(
+	totalNumberOfDecryptionServers int,
+	minPrime *big.Int,
+) error {
+	if big.NewInt(int64(totalNumberOfDecryptionServers)).Cmp(minPrime) != -1 {
+		return fmt.Errorf(
+			"Total number of decryption servers must be smaller than primes p1 and q1 which are at least %v",
+			minPrime,
+		)
+	}
+	return nil
+}
+
+// GetThresholdKeyGeneratorFromPrimes constructs the ThresholdKeyGenerator
+// from known safe primes `p = 2*p1 + 1` and `q = 2*q1 + 1` instead of
+// generating them. It is useful for tests and to reuse an already vetted
+// modulus `N = p*q`.
+//
+// The primes are validated and `Generate` does not search for new ones.
+// The total number of decryption servers must be smaller than `p1` and `q1`.
+func GetThresholdKeyGeneratorFromPrimes(
+	p, p1, q, q1 *big.Int,
+	totalNumberOfDecryptionServers int,
+	threshold int,
+	random RandReader,
+) (*ThresholdKeyGenerator, error) {
+	if !isSafePrimePair(p, p1) || !isSafePrimePair(q, q1) {
+		return nil, errors.New("p and q must be safe primes such that p=2*p1+1 and q=2*q1+1")
+	}
+	if err := checkTotalNumberOfDecryptionServers(
+		totalNumberOfDecryptionServers,
+	); err != nil {
+		return nil, err
+	}
+	minPrime := p1
+	if q1.Cmp(p1) < 0 {
+		minPrime = q1
+	}
+	if err := checkDecryptionServersBelowPrimes(
+		totalNumberOfDecryptionServers,
+		minPrime,
+	); err != nil {
+		return nil, err
+	}
+
+	tkg := &ThresholdKeyGenerator{
+		PublicKeyBitLength:             new(big.Int).Mul(p, q).BitLen(),
+		TotalNumberOfDecryptionServers: totalNumberOfDecryptionServers,
+		Threshold:                      threshold,
+		random:                         random,
+		p:                              p,
+		p1:                             p1,
+		q:                              q,
+		q1:                             q1,
+		fixedPrimes:                    true,
+		safePrimeConcurrencyLevel:      DefaultSafePrimeConcurrencyLevel,
+		safePrimeTimeout:               DefaultSafePrimeTimeout,
+	}
+	if !tkg.arePsAndQsGood() {
+		return nil, errors.New("p and q must be distinct")
+	}
+	return tkg, nil
+}
+
+// Checks whether `p` and `p1` are primes such that `p = 2*p1 + 1`.
+func isSafePrimePair(p, p1 *big.Int) bool {
+	expectedP := new(big.Int).Add(new(big.Int).Mul(TWO, p1), ONE)
+	return p.Cmp(expectedP) == 0 && p1.ProbablyPrime(20) && p.ProbablyPrime(20)
+}
+
+// GetThresholdKeyGeneratorStrict works like `GetThresholdKeyGenerator` but
+// additionally requires the public key bit length to be at least
+// `RecommendedMinPublicKeyBitLength`. It should be preferred in production.
+func GetThresholdKeyGeneratorStrict(
+	publicKeyBitLength int,
+	totalNumberOfDecryptionServers int,
+	threshold int,
+	random RandReader,
+) (*ThresholdKeyGenerator, error) {
+	if publicKeyBitLength < RecommendedMinPublicKeyBitLength {
+		return nil, fmt.Errorf(
+			"Public key bit length must be at least %v bits for production use",
+			RecommendedMinPublicKeyBitLength,
+		)
+	}
+	return GetThresholdKeyGenerator(
+		publicKeyBitLength,
+		totalNumberOfDecryptionServers,
+		threshold,
+		random,
+	)
+}
+
+// UseSafePrimePool makes the generator take its safe primes from `pool`
+// instead of searching for them on each `Generate`. The pool must provide
+// safe primes of `PublicKeyBitLength/2` bits.
+func (tkg *ThresholdKeyGenerator) UseSafePrimePool(pool *SafePrimePool) error {
+	if pool.BitLength != tkg.PublicKeyBitLength/2 {
+		return fmt.Errorf(
+			"Safe prime pool bit length must be %v bits",
+			tkg.PublicKeyBitLength/2,
+		)
+	}
+	tkg.pool = pool
+	return nil
+}
+
+// Checks that the pinned generator is a quadratic residue modulo n^2, that is
+// modulo both p and q since it is coprime with n, and uses it as v.
+func (tkg *ThresholdKeyGenerator) initFixedV() error {
+	if !ValidateGenerator(tkg.fixedV, tkg.nSquare) {
+		return errors.New("V is not a valid generator modulo N^2")
+	}
+	if big.Jacobi(tkg.fixedV, tkg.p) != 1 || big.Jacobi(tkg.fixedV, tkg.q) != 1 {
+		return errors.New("V is not a quadratic residue modulo N^2")
+	}
+	tkg.v = new(big.Int).Set(tkg.fixedV)
+	return nil
+}
+
+func (tkg *ThresholdKeyGenerator) generateSafePrimes(ctx context.Context) (*big.Int, *big.Int, error) {
+	if tkg.pool != nil {
+		return tkg.pool.GetContext(ctx)
+	}
+
 	safePrimeBitLength := tkg.PublicKeyBitLength / 2
 
-	return GenerateSafePrime(safePrimeBitLength, concurrencyLevel, timeout, tkg.random)
+	return GenerateSafePrimeContext(
+		ctx,
+		safePrimeBitLength,
+		tkg.safePrimeConcurrencyLevel,
+		tkg.safePrimeTimeout,
+		tkg.random,
+	)
 }
 
-func (tkg *ThresholdKeyGenerator) initPandP1() error {
+func (tkg *ThresholdKeyGenerator) initPandP1(ctx context.Context) error {
 	var err error
-	tkg.p, tkg.p1, err = tkg.generateSafePrimes()
+	tkg.p, tkg.p1, err = tkg.generateSafePrimes(ctx)
 	return err
 }
 
-func (tkg *ThresholdKeyGenerator) initQandQ1() error {
+func (tkg *ThresholdKeyGenerator) initQandQ1(ctx context.Context) error {
 	var err error
-	tkg.q, tkg.q1, err = tkg.generateSafePrimes()
+	tkg.q, tkg.q1, err = tkg.generateSafePrimes(ctx)
 	return err
 }
 
@@ -118,24 +333,73 @@ func (tkg *ThresholdKeyGenerator) arePsAndQsGood() bool {
 	return true
 }
 
-func (tkg *ThresholdKeyGenerator) initPsAndQs() error {
-	if err := tkg.initPandP1(); err != nil {
-		return err
+// Searches for `p` and `q` with `initPsAndQs` within the single
+// `safePrimeTimeout` budget shared by both searches and all their retries.
+func (tkg *ThresholdKeyGenerator) initPsAndQsWithTimeout(ctx context.Context) error {
+	searchCtx, cancel := context.WithTimeout(ctx, tkg.safePrimeTimeout)
+	defer cancel()
+
+	err := tkg.initPsAndQs(searchCtx)
+	if err != nil && ctx.Err() == nil && searchCtx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("safe prime search timed out after %v", tkg.safePrimeTimeout)
 	}
-	if err := tkg.initQandQ1(); err != nil {
-		return err
+	return err
+}
+
+// Searches for `p` and `q` concurrently, or one after another in the
+// deterministic mode, until they are distinct. Both searches always complete
+// before the function returns so that no goroutine is left writing to the
+// generator.
+func (tkg *ThresholdKeyGenerator) initPsAndQs(ctx context.Context) error {
+	if tkg.safePrimeConcurrencyLevel == 0 {
+		// In the deterministic mode, the searches must consume `random`
+		// one after another. Each of them stops reading it right after the
+		// safe prime is found.
+		if err := tkg.initPandP1(ctx); err != nil {
+			return err
+		}
+		if err := tkg.initQandQ1(ctx); err != nil {
+			return err
+		}
+	} else {
+		pErrChan := make(chan error, 1)
+		qErrChan := make(chan error, 1)
+		go func() { pErrChan <- tkg.initPandP1(ctx) }()
+		go func() { qErrChan <- tkg.initQandQ1(ctx) }()
+		pErr, qErr := <-pErrChan, <-qErrChan
+		if pErr != nil {
+			return pErr
+		}
+		if qErr != nil {
+			return qErr
+		}
 	}
 	if !tkg.arePsAndQsGood() {
-		return tkg.initPsAndQs()
+		return tkg.initPsAndQs(ctx)
 	}
 	return nil
 }
 
 // v generates a cyclic group of squares in Zn^2.
+// A new v is drawn until it passes `ValidateGenerator`.
+//
+// v is the square of a number drawn uniformly from the multiplicative group
+// modulo n^2, so it is uniformly distributed over the quadratic residues. For
+// n being a product of safe primes, almost all of them generate the whole
+// group and the candidates rejected by `ValidateGenerator` are very rare, so a
+// retry practically never happens for real key lengths. The candidates are
+// drawn only from `random`, so a deterministic reader gives a deterministic v.
 func (tkg *ThresholdKeyGenerator) computeV() error {
-	var err error
-	tkg.v, err = GetRandomGeneratorOfTheQuadraticResidue(tkg.nSquare, tkg.random)
-	return err
+	for {
+		v, err := GetRandomGeneratorOfTheQuadraticResidue(tkg.nSquare, tkg.random)
+		if err != nil {
+			return err
+		}
+		if ValidateGenerator(v, tkg.nSquare) {
+			tkg.v = v
+			return nil
+		}
+	}
 }
 
 // Choose d such that d=0 (mod m) and d=1 (mod n).
@@ -162,17 +426,28 @@ func (tkg *ThresholdKeyGenerator) computeV() error {
 // z2 = m^-1 mod n
 //
 // x = a2*y2*z2 = 1 * m * [m^-1 mod n]
-func (tkg *ThresholdKeyGenerator) initD() {
-	mInverse := new(big.Int).ModInverse(tkg.m, tkg.n)
+func (tkg *ThresholdKeyGenerator) initD() error {
+	mInverse, err := mustInverse(tkg.m, tkg.n)
+	if err != nil {
+		return err
+	}
 	tkg.d = new(big.Int).Mul(mInverse, tkg.m)
+	return nil
 }
 
-func (tkg *ThresholdKeyGenerator) initNumerialValues() error {
-	if err := tkg.initPsAndQs(); err != nil {
-		return err
+func (tkg *ThresholdKeyGenerator) initNumerialValues(ctx context.Context) error {
+	if !tkg.fixedPrimes {
+		if err := tkg.initPsAndQsWithTimeout(ctx); err != nil {
+			return err
+		}
 	}
 	tkg.initShortcuts()
-	tkg.initD()
+	if err := tkg.initD(); err != nil {
+		return err
+	}
+	if tkg.fixedV != nil {
+		return tkg.initFixedV()
+	}
 	return tkg.computeV()
 }
 
@@ -180,16 +455,26 @@ func (tkg *ThresholdKeyGenerator) initNumerialValues() error {
 //
 // where:
 // `w` - threshold
-// `a_i` - random value from {0, ... nm - 1} for 0<i<w
+// `a_i` - random value from {1, ... nm - 1} for 0<i<w
 // `a_0` is always equal `d`
+//
+// A zero coefficient is drawn with a negligible, but nonzero, probability of
+// 1/nm. A zero `a_(w-1)` would lower the degree of the polynomial and so the
+// number of shares needed to recover the secret. Zero coefficients are
+// redrawn to guarantee the intended degree.
 func (tkg *ThresholdKeyGenerator) generateHidingPolynomial() error {
 	tkg.polynomialCoefficients = make([]*big.Int, tkg.Threshold)
 	tkg.polynomialCoefficients[0] = tkg.d
-	var err error
 	for i := 1; i < tkg.Threshold; i++ {
-		tkg.polynomialCoefficients[i], err = rand.Int(tkg.random, tkg.nm)
-		if err != nil {
-			return err
+		for {
+			a, err := rand.Int(tkg.random, tkg.nm)
+			if err != nil {
+				return err
+			}
+			if a.Sign() != 0 {
+				tkg.polynomialCoefficients[i] = a
+				break
+			}
 		}
 	}
 	return nil
@@ -230,45 +515,178 @@ func (tkg *ThresholdKeyGenerator) delta() *big.Int {
 // `l` is the number of decryption servers
 // `s_i` is a secret share for server `i`.
 // Secret shares were previously generated in the `CrateShares` function.
-func (tkg *ThresholdKeyGenerator) createViArray(shares []*big.Int) (viArray []*big.Int) {
-	viArray = make([]*big.Int, len(shares))
+//
+// The computation is aborted with `ctx.Err()` if `ctx` is done.
+func (tkg *ThresholdKeyGenerator) createViArray(ctx context.Context, shares []*big.Int) ([]*big.Int, error) {
+	viArray := make([]*big.Int, len(shares))
 	delta := tkg.delta()
 	for i, share := range shares {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 		tmp := new(big.Int).Mul(share, delta)
 		viArray[i] = new(big.Int).Exp(tkg.v, tmp, tkg.nSquare)
 	}
-	return viArray
+	return viArray, nil
 }
 
+// Every key gets its own copy of `N`, `V` and `Vi` so that modifying one key
+// does not affect the others.
 func (tkg *ThresholdKeyGenerator) createPrivateKey(i int, share *big.Int, viArray []*big.Int) *ThresholdPrivateKey {
 	ret := new(ThresholdPrivateKey)
-	ret.N = tkg.n
-	ret.V = tkg.v
+	ret.N = new(big.Int).Add(tkg.n, big.NewInt(0))
+	ret.V = new(big.Int).Add(tkg.v, big.NewInt(0))
 
 	ret.TotalNumberOfDecryptionServers = tkg.TotalNumberOfDecryptionServers
 	ret.Threshold = tkg.Threshold
 	ret.Share = share
 	ret.Id = i + 1
-	ret.Vi = viArray
+	ret.Vi = make([]*big.Int, len(viArray))
+	for j, vi := range viArray {
+		ret.Vi[j] = new(big.Int).Add(vi, big.NewInt(0))
+	}
 	return ret
 }
 
-func (tkg *ThresholdKeyGenerator) createPrivateKeys() []*ThresholdPrivateKey {
+func (tkg *ThresholdKeyGenerator) createPrivateKeys(ctx context.Context) ([]*ThresholdPrivateKey, error) {
 	shares := tkg.createShares()
-	viArray := tkg.createViArray(shares)
+	viArray, err := tkg.createViArray(ctx, shares)
+	if err != nil {
+		return nil, err
+	}
 	ret := make([]*ThresholdPrivateKey, tkg.TotalNumberOfDecryptionServers)
 	for i := 0; i < tkg.TotalNumberOfDecryptionServers; i++ {
 		ret[i] = tkg.createPrivateKey(i, shares[i], viArray)
 	}
-	return ret
+	return ret, nil
 }
 
 func (tkg *ThresholdKeyGenerator) Generate() ([]*ThresholdPrivateKey, error) {
-	if err := tkg.initNumerialValues(); err != nil {
+	return tkg.GenerateContext(context.Background())
+}
+
+// GenerateContext works like `Generate` but the generation, which can take
+// minutes for long keys because of the safe prime search, can be aborted
+// with `ctx`. `ctx.Err()` is returned in such a case.
+func (tkg *ThresholdKeyGenerator) GenerateContext(ctx context.Context) ([]*ThresholdPrivateKey, error) {
+	if err := tkg.initNumerialValues(ctx); err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
 		return nil, err
 	}
 	if err := tkg.generateHidingPolynomial(); err != nil {
 		return nil, err
 	}
-	return tkg.createPrivateKeys(), nil
+	return tkg.createPrivateKeys(ctx)
+}
+
+// GenerateAndVerify works like `Generate` but before returning the keys it
+// checks that their shares actually reconstruct the secret: a random probe
+// is encrypted, decrypted with `Threshold` of the keys and the combined
+// message is compared with the probe. An error is returned if the probe is
+// not recovered. It is meant to be used by the dealer before distributing
+// the keys to decryption servers to catch a faulty key set at the source.
+func (tkg *ThresholdKeyGenerator) GenerateAndVerify() ([]*ThresholdPrivateKey, error) {
+	keys, err := tkg.Generate()
+	if err != nil {
+		return nil, err
+	}
+
+	probe, err := rand.Int(tkg.random, keys[0].N)
+	if err != nil {
+		return nil, err
+	}
+	cypher, err := keys[0].Encrypt(probe, tkg.random)
+	if err != nil {
+		return nil, err
+	}
+	message, err := ThresholdDecrypt(keys, cypher)
+	if err != nil {
+		return nil, err
+	}
+	if message.Cmp(probe) != 0 {
+		return nil, errors.New("generated threshold keys do not decrypt the probe message")
+	}
+	return keys, nil
+}
+
+// ThresholdKeyOption configures the `ThresholdKeyGenerator` used by
+// `GenerateThresholdKeys`. An option can also be applied directly to any
+// generator, e.g. `WithGenerator(v)(tkg)`.
+type ThresholdKeyOption func(*ThresholdKeyGenerator) error
+
+// WithSafePrimeTimeout sets the time after which the search for the two safe
+// primes is given up. The budget is shared by both searches, see
+// `DefaultSafePrimeTimeout` which is used otherwise.
+func WithSafePrimeTimeout(timeout time.Duration) ThresholdKeyOption {
+	return func(tkg *ThresholdKeyGenerator) error {
+		if timeout <= 0 {
+			return errors.New("safe prime timeout must be positive")
+		}
+		tkg.safePrimeTimeout = timeout
+		return nil
+	}
+}
+
+// WithSafePrimeConcurrencyLevel sets the number of routines searching for each
+// of the two safe primes. `0` selects the deterministic mode of
+// `GenerateSafePrime` and the two safe primes are then searched for one after
+// another. Each search reads `random` only up to the safe prime it returns, so
+// the same `random` content always yields the same keys.
+// `DefaultSafePrimeConcurrencyLevel` is used otherwise.
+func WithSafePrimeConcurrencyLevel(concurrencyLevel int) ThresholdKeyOption {
+	return func(tkg *ThresholdKeyGenerator) error {
+		if concurrencyLevel < 0 {
+			return errors.New("safe prime concurrency level can not be negative")
+		}
+		tkg.safePrimeConcurrencyLevel = concurrencyLevel
+		return nil
+	}
+}
+
+// WithGenerator pins the generator `v` of the quadratic residues in Z_{N^2}
+// instead of drawing it randomly, e.g. to produce test vectors shared with
+// other implementations. Together with `GetThresholdKeyGeneratorFromPrimes`
+// and a deterministic reader, it makes `Generate` fully reproducible.
+//
+// `v` is validated by `Generate`, once N is known. It must be a quadratic
+// residue modulo N^2 and pass `ValidateGenerator`.
+func WithGenerator(v *big.Int) ThresholdKeyOption {
+	return func(tkg *ThresholdKeyGenerator) error {
+		if v == nil {
+			return errors.New("generator can not be nil")
+		}
+		tkg.fixedV = v
+		return nil
+	}
+}
+
+// GenerateThresholdKeys generates the keys of all `totalNumberOfDecryptionServers`
+// decryption servers, any `threshold` of which can decrypt together. It
+// combines `GetThresholdKeyGenerator` and `Generate` in one call; the
+// parameters are validated the same way. The safe prime search can be
+// configured with `options`.
+func GenerateThresholdKeys(
+	publicKeyBitLength int,
+	totalNumberOfDecryptionServers int,
+	threshold int,
+	random RandReader,
+	options ...ThresholdKeyOption,
+) ([]*ThresholdPrivateKey, error) {
+	tkg, err := GetThresholdKeyGenerator(
+		publicKeyBitLength,
+		totalNumberOfDecryptionServers,
+		threshold,
+		random,
+	)
+	if err != nil {
+		return nil, err
+	}
+	for _, option := range options {
+		if err := option(tkg); err != nil {
+			return nil, err
+		}
+	}
+	return tkg.Generate()
 }
@@ -1,11 +1,17 @@
 package paillier
 
 import (
+	"bytes"
+	"context"
 	"crypto/rand"
 	"errors"
+	"fmt"
+	"io"
 	"math/big"
+	mrand "math/rand"
 	"reflect"
 	"testing"
+	"time"
 )
 
 var MockGenerateSafePrimes = func() (*big.Int, *big.Int, error) {
@@ -47,6 +53,12 @@ func TestCreateThresholdKeyGenerator(t *testing.T) {
 			threshold:                      3,
 			expectedError:                  errors.New("Public key bit length must be at least 18 bits"),
 		},
+		"generator can't be created for 1001 decryption servers": {
+			publicKeyBitLength:             32,
+			totalNumberOfDecryptionServers: 1001,
+			threshold:                      3,
+			expectedError:                  errors.New("Total number of decryption servers must be at most 1000"),
+		},
 	}
 
 	for testName, test := range tests {
@@ -101,6 +113,90 @@ func TestCreateThresholdKeyGenerator(t *testing.T) {
 	}
 }
 
+func TestMinPublicKeyBitLength(t *testing.T) {
+	if _, err := GetThresholdKeyGenerator(MinPublicKeyBitLength, 4, 3, rand.Reader); err != nil {
+		t.Errorf("Unexpected error for %v bit key length: %v", MinPublicKeyBitLength, err)
+	}
+
+	expectedError := fmt.Errorf(
+		"Public key bit length must be at least %v bits",
+		MinPublicKeyBitLength,
+	)
+	_, err := GetThresholdKeyGenerator(MinPublicKeyBitLength-2, 4, 3, rand.Reader)
+	if !reflect.DeepEqual(expectedError, err) {
+		t.Errorf(
+			"Unexpected error\nActual: %v\nExpected: %v",
+			err,
+			expectedError,
+		)
+	}
+}
+
+func TestGetThresholdKeyGeneratorBitLengthTooSmall(t *testing.T) {
+	var tests = map[string]struct {
+		publicKeyBitLength             int
+		totalNumberOfDecryptionServers int
+		expectedError                  error
+	}{
+		"too small to halve into safe primes": {
+			publicKeyBitLength:             10,
+			totalNumberOfDecryptionServers: 4,
+			expectedError: errors.New(
+				"Public key bit length of 10 bits is too small to be split into two safe primes of at least 6 bits",
+			),
+		},
+		"too small for the number of servers": {
+			publicKeyBitLength:             MinPublicKeyBitLength,
+			totalNumberOfDecryptionServers: 128,
+			expectedError: errors.New(
+				"Total number of decryption servers must be smaller than primes p1 and q1 which are at least 128",
+			),
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			_, err := GetThresholdKeyGenerator(
+				test.publicKeyBitLength,
+				test.totalNumberOfDecryptionServers,
+				3,
+				rand.Reader,
+			)
+			if !reflect.DeepEqual(test.expectedError, err) {
+				t.Errorf(
+					"Unexpected error\nActual: %v\nExpected: %v",
+					err,
+					test.expectedError,
+				)
+			}
+		})
+	}
+
+	if _, err := GetThresholdKeyGenerator(MinPublicKeyBitLength, 127, 3, rand.Reader); err != nil {
+		t.Errorf("Unexpected error for 127 decryption servers: %v", err)
+	}
+}
+
+func TestGetThresholdKeyGeneratorStrict(t *testing.T) {
+	if _, err := GetThresholdKeyGeneratorStrict(
+		RecommendedMinPublicKeyBitLength, 4, 3, rand.Reader,
+	); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	expectedError := errors.New(
+		"Public key bit length must be at least 2048 bits for production use",
+	)
+	_, err := GetThresholdKeyGeneratorStrict(1024, 4, 3, rand.Reader)
+	if !reflect.DeepEqual(expectedError, err) {
+		t.Errorf(
+			"Unexpected error\nActual: %v\nExpected: %v",
+			err,
+			expectedError,
+		)
+	}
+}
+
 func TestGenerateNumbersOfCorrectBitLength(t *testing.T) {
 	var tests = map[string]struct {
 		publicKeyLength     int
@@ -123,7 +219,7 @@ func TestGenerateNumbersOfCorrectBitLength(t *testing.T) {
 				t.Fatal(err)
 			}
 
-			err = gen.initNumerialValues()
+			err = gen.initNumerialValues(context.Background())
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -165,7 +261,7 @@ func TestInitPandP1(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	tkh.initPandP1()
+	tkh.initPandP1(context.Background())
 	IsSafePrime(tkh.p, tkh.p1, 16, t)
 }
 
@@ -175,7 +271,7 @@ func TestInitQandQ1(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	tkh.initQandQ1()
+	tkh.initQandQ1(context.Background())
 	IsSafePrime(tkh.q, tkh.q1, 16, t)
 }
 
@@ -185,12 +281,41 @@ func TestInitPsAndQs(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	tkh.initPsAndQs()
+	tkh.initPsAndQs(context.Background())
 
 	IsSafePrime(tkh.p, tkh.p1, 16, t)
 	IsSafePrime(tkh.q, tkh.q1, 16, t)
 }
 
+func BenchmarkInitPsAndQsSequentially(b *testing.B) {
+	tkh, err := GetThresholdKeyGenerator(1024, 4, 3, rand.Reader)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	for i := 0; i < b.N; i++ {
+		if err := tkh.initPandP1(context.Background()); err != nil {
+			b.Fatal(err)
+		}
+		if err := tkh.initQandQ1(context.Background()); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkInitPsAndQsConcurrently(b *testing.B) {
+	tkh, err := GetThresholdKeyGenerator(1024, 4, 3, rand.Reader)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	for i := 0; i < b.N; i++ {
+		if err := tkh.initPsAndQs(context.Background()); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
 func TestArePsAndQsGood(t *testing.T) {
 	tkh := new(ThresholdKeyGenerator)
 	tkh.p, tkh.p1, tkh.q, tkh.q1 = b(887), b(443), b(839), b(419)
@@ -232,7 +357,9 @@ func TestInitD(t *testing.T) {
 	tkh := new(ThresholdKeyGenerator)
 	tkh.p, tkh.p1, tkh.q, tkh.q1 = b(863), b(431), b(839), b(419)
 	tkh.initShortcuts()
-	tkh.initD()
+	if err := tkh.initD(); err != nil {
+		t.Fatal(err)
+	}
 	if n(tkh.d)%n(tkh.m) != 0 {
 		t.Fail()
 	}
@@ -247,7 +374,7 @@ func TestInitNumerialValues(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if err := tkh.initNumerialValues(); err != nil {
+	if err := tkh.initNumerialValues(context.Background()); err != nil {
 		t.Error(err)
 	}
 }
@@ -258,7 +385,7 @@ func TestGenerateHidingPolynomial(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if err := tkh.initNumerialValues(); err != nil {
+	if err := tkh.initNumerialValues(context.Background()); err != nil {
 		t.Error(err)
 	}
 	if err := tkh.generateHidingPolynomial(); err != nil {
@@ -278,6 +405,27 @@ func TestGenerateHidingPolynomial(t *testing.T) {
 	}
 }
 
+func TestGenerateHidingPolynomialRedrawsZeroCoefficient(t *testing.T) {
+	tkh := new(ThresholdKeyGenerator)
+	tkh.Threshold = 2
+	tkh.d = b(29)
+	tkh.nm = b(103)
+
+	// the first byte read by rand.Int is zero so the first draw is zero
+	zeros := bytes.NewReader([]byte{0})
+	tkh.random = io.MultiReader(zeros, rand.Reader)
+
+	if err := tkh.generateHidingPolynomial(); err != nil {
+		t.Fatal(err)
+	}
+	if zeros.Len() != 0 {
+		t.Fatal("zero coefficient has not been drawn")
+	}
+	if tkh.polynomialCoefficients[1].Sign() == 0 {
+		t.Error("zero coefficient has not been redrawn")
+	}
+}
+
 func TestComputeShare(t *testing.T) {
 	tkh, err := GetThresholdKeyGenerator(32, 5, 3, rand.Reader)
 	if err != nil {
@@ -298,7 +446,7 @@ func TestCreateShares(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if err := tkh.initNumerialValues(); err != nil {
+	if err := tkh.initNumerialValues(context.Background()); err != nil {
 		t.Error(err)
 	}
 	if err := tkh.generateHidingPolynomial(); err != nil {
@@ -315,7 +463,13 @@ func TestCreateViArray(t *testing.T) {
 	tkh.TotalNumberOfDecryptionServers = 10
 	tkh.v = b(54)
 	tkh.nSquare = b(101 * 101)
-	vArr := tkh.createViArray([]*big.Int{b(12), b(90), b(103)})
+	vArr, err := tkh.createViArray(
+		context.Background(),
+		[]*big.Int{b(12), b(90), b(103)},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
 	exp := []*big.Int{b(6162), b(304), b(2728)}
 	if !reflect.DeepEqual(vArr, exp) {
 		t.Fail()
@@ -328,7 +482,7 @@ func TestGetThresholdKeyGenerator(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if err := tkh.initNumerialValues(); err != nil {
+	if err := tkh.initNumerialValues(context.Background()); err != nil {
 		t.Error(nil)
 	}
 }
@@ -363,6 +517,80 @@ func TestGenerate(t *testing.T) {
 	}
 }
 
+func TestGenerateAndVerify(t *testing.T) {
+	tkh, err := GetThresholdKeyGenerator(32, 5, 3, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tpks, err := tkh.GenerateAndVerify()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tpks) != 5 {
+		t.Errorf(
+			"Unexpected number of keys\nExpected: %v\nActual: %v",
+			5,
+			len(tpks),
+		)
+	}
+}
+
+func TestGeneratedKeysDoNotShareValues(t *testing.T) {
+	tkh, err := GetThresholdKeyGenerator(32, 3, 2, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tpks, err := tkh.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	vi0 := new(big.Int).Set(tpks[1].Vi[0])
+	n := new(big.Int).Set(tpks[1].N)
+	v := new(big.Int).Set(tpks[1].V)
+
+	tpks[0].Vi[0].SetInt64(1)
+	tpks[0].N.SetInt64(1)
+	tpks[0].V.SetInt64(1)
+
+	if tpks[1].Vi[0].Cmp(vi0) != 0 {
+		t.Error("modifying Vi of one key should not affect another key")
+	}
+	if tpks[1].N.Cmp(n) != 0 {
+		t.Error("modifying N of one key should not affect another key")
+	}
+	if tpks[1].V.Cmp(v) != 0 {
+		t.Error("modifying V of one key should not affect another key")
+	}
+}
+
+func TestGenerateContextCancelled(t *testing.T) {
+	tkh, err := GetThresholdKeyGenerator(4096, 10, 6, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		cancel()
+	}()
+
+	start := time.Now()
+	_, err = tkh.GenerateContext(ctx)
+	if err != context.Canceled {
+		t.Errorf(
+			"Unexpected error\nExpected: %v\nActual: %v",
+			context.Canceled,
+			err,
+		)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("generation has not been aborted promptly, took %v", elapsed)
+	}
+}
+
 func TestComputeV(t *testing.T) {
 	tkh, err := GetThresholdKeyGenerator(32, 10, 6, rand.Reader)
 	if err != nil {
@@ -386,3 +614,398 @@ func TestComputeV(t *testing.T) {
 	than it was taken in the range 0...n**2 -1
 	`)
 }
+
+func TestComputeVRetriesBadCandidate(t *testing.T) {
+	tkh, err := GetThresholdKeyGenerator(32, 10, 6, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// n^2 = 1168561 is a 21-bit number so every candidate is drawn from
+	// 3 bytes. The first candidate is 1 which squared gives v = 1, a bad
+	// generator. The second candidate is 256 which squared gives v = 65536.
+	tkh.n = b(23 * 47)
+	tkh.nSquare = new(big.Int).Mul(tkh.n, tkh.n)
+	random := &countingReader{
+		reader: bytes.NewReader([]byte{0x00, 0x00, 0x01, 0x00, 0x01, 0x00}),
+	}
+	tkh.random = random
+
+	if err := tkh.computeV(); err != nil {
+		t.Fatal(err)
+	}
+	if random.reads != 2 {
+		t.Errorf(
+			"Unexpected number of candidates\nExpected: %v\nActual: %v",
+			2,
+			random.reads,
+		)
+	}
+	if tkh.v.Cmp(b(65536)) != 0 {
+		t.Errorf(
+			"Unexpected v\nExpected: %v\nActual: %v",
+			65536,
+			tkh.v,
+		)
+	}
+}
+
+func TestGetThresholdKeyGeneratorFromPrimes(t *testing.T) {
+	p, p1, err := MockGenerateSafePrimes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	q, q1 := b(839), b(419)
+
+	tkh, err := GetThresholdKeyGeneratorFromPrimes(p, p1, q, q1, 5, 3, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tkh.PublicKeyBitLength != 20 {
+		t.Error("wrong public key bit length ", tkh.PublicKeyBitLength)
+	}
+
+	tpks, err := tkh.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tpks[0].N.Cmp(b(887*839)) != 0 {
+		t.Error("wrong N ", tpks[0].N)
+	}
+
+	c, err := tpks[0].Encrypt(b(100), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if message, err := ThresholdDecrypt(tpks, c); err != nil {
+		t.Error(err)
+	} else if n(message) != 100 {
+		t.Error("decrypted message was not 100 but ", message)
+	}
+}
+
+func TestWithGenerator(t *testing.T) {
+	generate := func(v *big.Int) ([]*ThresholdPrivateKey, error) {
+		tkh, err := GetThresholdKeyGeneratorFromPrimes(
+			b(887), b(443), b(839), b(419), 5, 3,
+			bytes.NewReader(bytes.Repeat([]byte{0x5a, 0x17, 0xc3}, 1024)),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := WithGenerator(v)(tkh); err != nil {
+			t.Fatal(err)
+		}
+		return tkh.Generate()
+	}
+
+	tpks1, err := generate(b(4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tpks2, err := generate(b(4))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tpks1[0].V.Cmp(b(4)) != 0 {
+		t.Errorf("Unexpected V [%v]", tpks1[0].V)
+	}
+	if !reflect.DeepEqual(tpks1[0].Vi, tpks2[0].Vi) {
+		t.Errorf(
+			"Unexpected Vi\nExpected: %v\nActual: %v",
+			tpks1[0].Vi,
+			tpks2[0].Vi,
+		)
+	}
+
+	// 5 is not a quadratic residue modulo 887
+	if _, err := generate(b(5)); err == nil {
+		t.Error("expected an error for V not being a quadratic residue")
+	}
+	if _, err := generate(b(1)); err == nil {
+		t.Error("expected an error for V equal 1")
+	}
+
+	tpks, err := GenerateThresholdKeys(64, 3, 2, rand.Reader, WithGenerator(b(4)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tpks[0].V.Cmp(b(4)) != 0 {
+		t.Errorf("Unexpected V [%v]", tpks[0].V)
+	}
+
+	if _, err := GenerateThresholdKeys(64, 3, 2, rand.Reader, WithGenerator(nil)); err == nil {
+		t.Error("expected an error for a nil V")
+	}
+}
+
+func TestGetThresholdKeyGeneratorFromInvalidPrimes(t *testing.T) {
+	var tests = map[string]struct {
+		p, p1, q, q1 *big.Int
+	}{
+		"p is not 2*p1+1": {
+			p: b(887), p1: b(419), q: b(839), q1: b(419),
+		},
+		"p1 is not prime": {
+			p: b(31), p1: b(15), q: b(839), q1: b(419),
+		},
+		"p equal q": {
+			p: b(887), p1: b(443), q: b(887), q1: b(443),
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			_, err := GetThresholdKeyGeneratorFromPrimes(
+				test.p, test.p1, test.q, test.q1, 5, 3, rand.Reader,
+			)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
+
+func TestGetThresholdKeyGeneratorFromPrimesTooSmallForServers(t *testing.T) {
+	// delta = 20! is not invertible modulo N for p1 = 11
+	_, err := GetThresholdKeyGeneratorFromPrimes(
+		b(23), b(11), b(839), b(419), 20, 3, rand.Reader,
+	)
+
+	expectedError := errors.New(
+		"Total number of decryption servers must be smaller than primes p1 and q1 which are at least 11",
+	)
+	if !reflect.DeepEqual(expectedError, err) {
+		t.Errorf(
+			"Unexpected error\nExpected: %v\nActual: %v",
+			expectedError,
+			err,
+		)
+	}
+
+	if _, err := GetThresholdKeyGeneratorFromPrimes(
+		b(23), b(11), b(839), b(419), 10, 3, rand.Reader,
+	); err != nil {
+		t.Errorf("Unexpected error for 10 servers [%v]", err)
+	}
+}
+
+func TestGenerateThresholdKeys(t *testing.T) {
+	tpks, err := GenerateThresholdKeys(
+		64,
+		3,
+		2,
+		rand.Reader,
+		WithSafePrimeTimeout(60*time.Second),
+		WithSafePrimeConcurrencyLevel(2),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tpks) != 3 {
+		t.Fatalf("Unexpected number of keys\nExpected: %v\nActual: %v", 3, len(tpks))
+	}
+
+	message := big.NewInt(100)
+	c, err := tpks[2].Encrypt(message, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	share1, err := tpks[0].DecryptAndProduceZKP(c.C, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	share2, err := tpks[2].DecryptAndProduceZKP(c.C, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decrypted, err := tpks[1].CombinePartialDecryptionsZKP(
+		[]*PartialDecryptionZKP{share1, share2},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decrypted.Cmp(message) != 0 {
+		t.Errorf(
+			"Unexpected decryption\nExpected: %v\nActual: %v",
+			message,
+			decrypted,
+		)
+	}
+}
+
+func TestGenerateThresholdKeysInvalidOptions(t *testing.T) {
+	var tests = map[string]struct {
+		option        ThresholdKeyOption
+		expectedError error
+	}{
+		"non-positive timeout": {
+			option:        WithSafePrimeTimeout(0),
+			expectedError: errors.New("safe prime timeout must be positive"),
+		},
+		"negative concurrency level": {
+			option:        WithSafePrimeConcurrencyLevel(-1),
+			expectedError: errors.New("safe prime concurrency level can not be negative"),
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			_, err := GenerateThresholdKeys(64, 3, 2, rand.Reader, test.option)
+			if !reflect.DeepEqual(test.expectedError, err) {
+				t.Errorf(
+					"Unexpected error\nExpected: %v\nActual: %v",
+					test.expectedError,
+					err,
+				)
+			}
+		})
+	}
+}
+
+func TestGenerateThresholdKeysDeterministic(t *testing.T) {
+	generate := func() *big.Int {
+		tpks, err := GenerateThresholdKeys(
+			128,
+			3,
+			2,
+			mrand.New(mrand.NewSource(42)),
+			WithSafePrimeConcurrencyLevel(0),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tpks[0].N
+	}
+
+	n1 := generate()
+	for i := 0; i < 5; i++ {
+		if n2 := generate(); n1.Cmp(n2) != 0 {
+			t.Fatalf(
+				"Unexpected modulus for the same reader\nExpected: %v\nActual: %v",
+				n1,
+				n2,
+			)
+		}
+	}
+}
+
+// Returns `first` after `delay` on the first read and zeros afterwards.
+// The safe prime search reading zeros keeps testing the same candidate, which
+// is not a safe prime, so it never succeeds.
+type delayedReader struct {
+	delay time.Duration
+	first []byte
+	read  bool
+}
+
+func (r *delayedReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 0
+	}
+	if !r.read {
+		r.read = true
+		time.Sleep(r.delay)
+		copy(p, r.first)
+	}
+	return len(p), nil
+}
+
+func TestGenerateThresholdKeysSafePrimeTimeout(t *testing.T) {
+	timeout := time.Second
+
+	// In the sequential mode, `p` is found after 70% of the budget and `q` is
+	// never found. With a budget for each search, the generation would last
+	// for 170% of the budget.
+	_, q, err := GenerateSafePrime(64, 4, 10*time.Second, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reader := &delayedReader{
+		delay: timeout * 7 / 10,
+		first: q.FillBytes(make([]byte, 8)),
+	}
+
+	start := time.Now()
+	_, err = GenerateThresholdKeys(
+		128,
+		3,
+		2,
+		reader,
+		WithSafePrimeConcurrencyLevel(0),
+		WithSafePrimeTimeout(timeout),
+	)
+	elapsed := time.Since(start)
+
+	expectedError := fmt.Errorf("safe prime search timed out after %v", timeout)
+	if !reflect.DeepEqual(expectedError, err) {
+		t.Errorf(
+			"Unexpected error\nExpected: %v\nActual: %v",
+			expectedError,
+			err,
+		)
+	}
+	if !reader.read {
+		t.Error("the first safe prime has not been searched for")
+	}
+	// p and q are searched within one budget, not one budget each
+	if elapsed >= timeout*135/100 {
+		t.Errorf("generation took %v, more than the %v budget", elapsed, timeout)
+	}
+}
+
+// Reads from `rand.Reader` until `stop` is closed and fails afterwards.
+type stoppableReader struct {
+	stop chan struct{}
+}
+
+func (r *stoppableReader) Read(p []byte) (int, error) {
+	select {
+	case <-r.stop:
+		return 0, errors.New("reader stopped")
+	default:
+		return rand.Read(p)
+	}
+}
+
+func TestGenerateWithDrainedSafePrimePoolTimeout(t *testing.T) {
+	timeout := 500 * time.Millisecond
+
+	// The pool can't find a 1024-bit safe prime in time, so the generator
+	// searches on its own and must respect its budget.
+	reader := &stoppableReader{stop: make(chan struct{})}
+	defer close(reader.stop)
+	pool, err := GetSafePrimePool(1024, 1, reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+
+	tkh, err := GetThresholdKeyGenerator(2048, 3, 2, reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tkh.UseSafePrimePool(pool); err != nil {
+		t.Fatal(err)
+	}
+	if err := WithSafePrimeTimeout(timeout)(tkh); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	_, err = tkh.Generate()
+	elapsed := time.Since(start)
+
+	expectedError := fmt.Errorf("safe prime search timed out after %v", timeout)
+	if !reflect.DeepEqual(expectedError, err) {
+		t.Errorf(
+			"Unexpected error\nExpected: %v\nActual: %v",
+			expectedError,
+			err,
+		)
+	}
+	if elapsed >= 2*timeout {
+		t.Errorf("generation took %v, more than the %v budget", elapsed, timeout)
+	}
+}
@@ -1,10 +1,16 @@
 package paillier
 
 import (
+	"bytes"
+	"context"
 	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
 	"math/big"
 	"reflect"
 	"testing"
+	"time"
 )
 
 func getThresholdPrivateKey() *ThresholdPrivateKey {
@@ -28,19 +34,54 @@ func TestDelta(t *testing.T) {
 	}
 }
 
-func TestExp(t *testing.T) {
-	tk := new(ThresholdPublicKey)
+func TestThresholdPublicKeyEqual(t *testing.T) {
+	newKey := func() *ThresholdPublicKey {
+		return &ThresholdPublicKey{
+			PublicKey:                      PublicKey{N: b(637753)},
+			TotalNumberOfDecryptionServers: 3,
+			Threshold:                      2,
+			V:                              b(70661107826),
+			Vi:                             []*big.Int{b(34), b(2), b(29)},
+		}
+	}
+	tk := newKey()
+
+	if !tk.Equal(newKey()) {
+		t.Error("keys with the same values should be equal")
+	}
+
+	other := newKey()
+	other.Vi[1] = b(3)
+	if tk.Equal(other) {
+		t.Error("keys with a different Vi element should not be equal")
+	}
 
-	if exp := tk.exp(big.NewInt(720), big.NewInt(10), big.NewInt(49)); 43 != n(exp) {
-		t.Error("Unexpected exponent. Expected 43 but got", exp)
+	other = newKey()
+	other.Vi = other.Vi[:2]
+	if tk.Equal(other) {
+		t.Error("keys with a different number of Vi should not be equal")
 	}
 
-	if exp := tk.exp(big.NewInt(720), big.NewInt(0), big.NewInt(49)); 1 != n(exp) {
-		t.Error("Unexpected exponent. Expected 0 but got", exp)
+	other = newKey()
+	other.V = b(7)
+	if tk.Equal(other) {
+		t.Error("keys with different V should not be equal")
 	}
 
-	if exp := tk.exp(big.NewInt(720), big.NewInt(-10), big.NewInt(49)); 8 != n(exp) {
-		t.Error("Unexpected exponent. Expected 8 but got", exp)
+	other = newKey()
+	other.N = b(11)
+	if tk.Equal(other) {
+		t.Error("keys with different N should not be equal")
+	}
+
+	other = newKey()
+	other.Threshold = 3
+	if tk.Equal(other) {
+		t.Error("keys with different threshold should not be equal")
+	}
+
+	if tk.Equal(nil) {
+		t.Error("key should not be equal to nil")
 	}
 }
 
@@ -49,8 +90,18 @@ func TestCombineSharesConstant(t *testing.T) {
 	tk.N = big.NewInt(101 * 103)
 	tk.TotalNumberOfDecryptionServers = 6
 
-	if c := tk.combineSharesConstant(); !reflect.DeepEqual(big.NewInt(4558), c) {
-		t.Error("wrong combined key.  ", c)
+	if c, err := tk.combineSharesConstant(); err != nil || !reflect.DeepEqual(big.NewInt(4558), c) {
+		t.Error("wrong combined key.  ", c, err)
+	}
+
+	// 4*delta^2 shares the factor 101 with N
+	tk.TotalNumberOfDecryptionServers = 101
+	if _, err := tk.combineSharesConstant(); err != ErrNotInvertible {
+		t.Errorf(
+			"Unexpected error\nExpected: %v\nActual: %v",
+			ErrNotInvertible,
+			err,
+		)
 	}
 }
 
@@ -102,6 +153,26 @@ func TestDecryptWithThresholdKey(t *testing.T) {
 	pd.Decrypt(c.C)
 }
 
+func TestRawPartialDecrypt(t *testing.T) {
+	pd := getThresholdPrivateKey()
+	c, err := pd.Encrypt(big.NewInt(876), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	raw := pd.RawPartialDecrypt(c.C)
+	twoDelta := new(big.Int).Mul(TWO, pd.delta())
+	scaled := new(big.Int).Exp(raw, twoDelta, pd.GetNSquare())
+
+	if expected := pd.Decrypt(c.C).Decryption; scaled.Cmp(expected) != 0 {
+		t.Errorf(
+			"Unexpected scaled raw partial decryption\nExpected: %v\nActual: %v",
+			expected,
+			scaled,
+		)
+	}
+}
+
 func TestVerifyPart1(t *testing.T) {
 	pd := new(PartialDecryptionZKP)
 	pd.Key = new(ThresholdPublicKey)
@@ -111,7 +182,9 @@ func TestVerifyPart1(t *testing.T) {
 	pd.E = b(112)
 	pd.Z = b(88)
 
-	if a := pd.verifyPart1(); n(a) != 11986 {
+	if a, err := pd.verifyPart1(); err != nil {
+		t.Error(err)
+	} else if n(a) != 11986 {
 		t.Error("wrong a ", a)
 	}
 }
@@ -125,18 +198,56 @@ func TestVerifyPart2(t *testing.T) {
 	pd.Key.V = b(101)
 	pd.E = b(112)
 	pd.Z = b(88)
-	if b := pd.verifyPart2(); n(b) != 14602 {
+	if b, err := pd.verifyPart2(); err != nil {
+		t.Error(err)
+	} else if n(b) != 14602 {
 		t.Error("wrong b ", b)
 	}
 }
 
-func TestDecryptAndProduceZNP(t *testing.T) {
+func TestVerifyRejectsOutOfRangeId(t *testing.T) {
+	pd := getThresholdPrivateKey()
+	c, err := pd.Encrypt(big.NewInt(876), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var tests = map[string]struct {
+		id int
+	}{
+		"zero": {
+			id: 0,
+		},
+		"negative": {
+			id: -1,
+		},
+		"one past the end": {
+			id: len(pd.Vi) + 1,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			zkp, err := pd.DecryptAndProduceZKP(c.C, rand.Reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+			zkp.Id = test.id
+
+			if zkp.Verify() {
+				t.Errorf("proof with id %v should not be valid", test.id)
+			}
+		})
+	}
+}
+
+func TestDecryptAndProduceZKP(t *testing.T) {
 	pd := getThresholdPrivateKey()
 	c, err := pd.Encrypt(big.NewInt(876), rand.Reader)
 	if err != nil {
 		t.Error(err)
 	}
-	znp, err := pd.DecryptAndProduceZNP(c.C, rand.Reader)
+	znp, err := pd.DecryptAndProduceZKP(c.C, rand.Reader)
 	if err != nil {
 		t.Error(err)
 	}
@@ -146,6 +257,135 @@ func TestDecryptAndProduceZNP(t *testing.T) {
 	}
 }
 
+func TestPartialDecryptionZKPTranscript(t *testing.T) {
+	pd := getThresholdPrivateKey()
+	c, err := pd.Encrypt(big.NewInt(876), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zkp, err := pd.DecryptAndProduceZKPWithLabel(c.C, []byte("audit"), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a, b, c4, ci2 := zkp.Transcript()
+	if a == nil || b == nil {
+		t.Fatal("a and b should be recomputed for a valid proof")
+	}
+
+	hash := sha256.New()
+	hash.Write([]byte("audit"))
+	hash.Write(a.Bytes())
+	hash.Write(b.Bytes())
+	hash.Write(c4.Bytes())
+	hash.Write(ci2.Bytes())
+	e := new(big.Int).SetBytes(hash.Sum(nil))
+
+	if e.Cmp(zkp.E) != 0 {
+		t.Errorf(
+			"Unexpected transcript hash\nExpected: %v\nActual: %v",
+			zkp.E,
+			e,
+		)
+	}
+}
+
+func TestDecryptAndProduceZKPWithLabel(t *testing.T) {
+	pd := getThresholdPrivateKey()
+	c, err := pd.Encrypt(big.NewInt(876), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zkp, err := pd.DecryptAndProduceZKPWithLabel(c.C, []byte("request-42"), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !zkp.VerifyWithLabel([]byte("request-42")) {
+		t.Error("proof should be valid for the matching label")
+	}
+	if zkp.VerifyWithLabel([]byte("request-43")) {
+		t.Error("proof should not be valid for a mismatched label")
+	}
+	if zkp.VerifyWithLabel(nil) {
+		t.Error("proof should not be valid without label")
+	}
+
+	zkp.Label = []byte("request-43")
+	if zkp.VerifyWithLabel([]byte("request-43")) {
+		t.Error("proof should not be valid once the label has been replaced")
+	}
+}
+
+func TestDecryptAndProduceZNPIsAliasOfZKP(t *testing.T) {
+	pd := getThresholdPrivateKey()
+	c, err := pd.Encrypt(big.NewInt(876), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	randomness := make([]byte, 1024)
+	if _, err := rand.Read(randomness); err != nil {
+		t.Fatal(err)
+	}
+
+	zkp, err := pd.DecryptAndProduceZKP(c.C, bytes.NewReader(randomness))
+	if err != nil {
+		t.Fatal(err)
+	}
+	znp, err := pd.DecryptAndProduceZNP(c.C, bytes.NewReader(randomness))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(zkp, znp) {
+		t.Errorf("Unexpected proof\nExpected: %v\nActual: %v", zkp, znp)
+	}
+}
+
+func TestDecryptAndProduceZKPSharedKey(t *testing.T) {
+	pd := getThresholdPrivateKey()
+	c, err := pd.Encrypt(big.NewInt(876), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	randomness := make([]byte, 1024)
+	if _, err := rand.Read(randomness); err != nil {
+		t.Fatal(err)
+	}
+
+	copied, err := pd.DecryptAndProduceZKPWithLabel(
+		c.C,
+		[]byte("request-42"),
+		bytes.NewReader(randomness),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	shared, err := pd.DecryptAndProduceZKPSharedKey(
+		c.C,
+		[]byte("request-42"),
+		bytes.NewReader(randomness),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(copied, shared) {
+		t.Errorf("Unexpected proof\nExpected: %v\nActual: %v", copied, shared)
+	}
+	if shared.Key != &pd.ThresholdPublicKey {
+		t.Error("proof should reference the public part of the key")
+	}
+	if copied.Key == &pd.ThresholdPublicKey || copied.Key.N == pd.N {
+		t.Error("proof should reference a copy of the public part of the key")
+	}
+	if !shared.VerifyWithLabel([]byte("request-42")) {
+		t.Error("proof should be valid")
+	}
+}
+
 func TestMakeVerificationBeforeCombiningPartialDecryptions(t *testing.T) {
 	tk := new(ThresholdPublicKey)
 	tk.Threshold = 2
@@ -163,6 +403,95 @@ func TestMakeVerificationBeforeCombiningPartialDecryptions(t *testing.T) {
 	}
 }
 
+func TestCombinePartialDecryptionsWithoutShares(t *testing.T) {
+	tk := new(ThresholdPublicKey)
+	tk.N = b(637753)
+	tk.TotalNumberOfDecryptionServers = 2
+
+	tk.Threshold = 1
+	_, err := tk.CombinePartialDecryptions([]*PartialDecryption{})
+	if !reflect.DeepEqual(errors.New("no partial decryptions supplied"), err) {
+		t.Error("Unexpected error ", err)
+	}
+
+	tk.Threshold = 0
+	_, err = tk.CombinePartialDecryptions([]*PartialDecryption{{1, b(384111638639)}})
+	if !reflect.DeepEqual(errors.New("Threshold must be at least 1"), err) {
+		t.Error("Unexpected error ", err)
+	}
+}
+
+func TestMissingShareIds(t *testing.T) {
+	tk := &ThresholdPublicKey{
+		TotalNumberOfDecryptionServers: 5,
+		Threshold:                      3,
+	}
+
+	var tests = map[string]struct {
+		presentIds           []int
+		expectedMissingIds   []int
+		expectedHasThreshold bool
+	}{
+		"no shares": {
+			presentIds:           []int{},
+			expectedMissingIds:   []int{1, 2, 3, 4, 5},
+			expectedHasThreshold: false,
+		},
+		"partial set below threshold": {
+			presentIds:           []int{4, 2},
+			expectedMissingIds:   []int{1, 3, 5},
+			expectedHasThreshold: false,
+		},
+		"duplicated shares": {
+			presentIds:           []int{2, 2, 4},
+			expectedMissingIds:   []int{1, 3, 5},
+			expectedHasThreshold: false,
+		},
+		"out of range ids": {
+			presentIds:           []int{0, 2, 4, 6},
+			expectedMissingIds:   []int{1, 3, 5},
+			expectedHasThreshold: false,
+		},
+		"partial set at threshold": {
+			presentIds:           []int{5, 1, 3},
+			expectedMissingIds:   []int{2, 4},
+			expectedHasThreshold: true,
+		},
+		"all shares": {
+			presentIds:           []int{1, 2, 3, 4, 5},
+			expectedMissingIds:   []int{},
+			expectedHasThreshold: true,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			present := make([]*PartialDecryption, len(test.presentIds))
+			for i, id := range test.presentIds {
+				present[i] = &PartialDecryption{Id: id}
+			}
+
+			missingIds := tk.MissingShareIds(present)
+			if !reflect.DeepEqual(test.expectedMissingIds, missingIds) {
+				t.Errorf(
+					"Unexpected missing ids\nExpected: %v\nActual: %v",
+					test.expectedMissingIds,
+					missingIds,
+				)
+			}
+
+			hasThreshold := tk.HasThreshold(present)
+			if hasThreshold != test.expectedHasThreshold {
+				t.Errorf(
+					"Unexpected threshold check\nExpected: %v\nActual: %v",
+					test.expectedHasThreshold,
+					hasThreshold,
+				)
+			}
+		})
+	}
+}
+
 func TestUpdateLambda(t *testing.T) {
 	tk := new(ThresholdPublicKey)
 	lambda := b(11)
@@ -180,7 +509,10 @@ func TestupdateCprime(t *testing.T) {
 	cprime := b(77)
 	lambda := b(52)
 	share := &PartialDecryption{3, b(5)}
-	cprime = tk.updateCprime(cprime, lambda, share)
+	cprime, err := tk.updateCprime(cprime, lambda, share, tk.GetNSquare())
+	if err != nil {
+		t.Fatal(err)
+	}
 	if n(cprime) != 8558 {
 		t.Error("wrong cprime", cprime)
 	}
@@ -293,6 +625,29 @@ func TestValidate(t *testing.T) {
 	}
 }
 
+func TestValidateContext(t *testing.T) {
+	pk := getThresholdPrivateKey()
+	if err := pk.ValidateContext(context.Background(), rand.Reader); err != nil {
+		t.Error(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	start := time.Now()
+	err := pk.ValidateContext(ctx, rand.Reader)
+	if err != context.Canceled {
+		t.Errorf(
+			"Unexpected error\nExpected: %v\nActual: %v",
+			context.Canceled,
+			err,
+		)
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("validation with cancelled context took %v", elapsed)
+	}
+}
+
 func TestCombinePartialDecryptionsZKP(t *testing.T) {
 	tkh, err := GetThresholdKeyGenerator(32, 2, 2, rand.Reader)
 	if err != nil {
@@ -308,11 +663,11 @@ func TestCombinePartialDecryptionsZKP(t *testing.T) {
 	if err != nil {
 		t.Error(err)
 	}
-	share1, err := tpks[0].DecryptAndProduceZNP(c.C, rand.Reader)
+	share1, err := tpks[0].DecryptAndProduceZKP(c.C, rand.Reader)
 	if err != nil {
 		t.Error(err)
 	}
-	share2, err := tpks[1].DecryptAndProduceZNP(c.C, rand.Reader)
+	share2, err := tpks[1].DecryptAndProduceZKP(c.C, rand.Reader)
 	if err != nil {
 		t.Error(err)
 	}
@@ -330,6 +685,240 @@ func TestCombinePartialDecryptionsZKP(t *testing.T) {
 	}
 }
 
+func TestIsZeroShares(t *testing.T) {
+	tkh, err := GetThresholdKeyGenerator(32, 3, 2, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tpks, err := tkh.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var tests = map[string]struct {
+		message        *big.Int
+		expectedIsZero bool
+	}{
+		"zero": {
+			message:        b(0),
+			expectedIsZero: true,
+		},
+		"non-zero": {
+			message:        b(100),
+			expectedIsZero: false,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			c, err := tpks[0].Encrypt(test.message, rand.Reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+			shares := []*PartialDecryption{
+				tpks[0].Decrypt(c.C),
+				tpks[2].Decrypt(c.C),
+			}
+
+			isZero, err := tpks[0].IsZeroShares(shares)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if isZero != test.expectedIsZero {
+				t.Errorf(
+					"Unexpected zero-test result\nExpected: %v\nActual: %v",
+					test.expectedIsZero,
+					isZero,
+				)
+			}
+		})
+	}
+
+	if _, err := tpks[0].IsZeroShares(nil); err == nil {
+		t.Error("expected error for no shares")
+	}
+}
+
+func TestPrepareEqualityToConstant(t *testing.T) {
+	tkh, err := GetThresholdKeyGenerator(32, 3, 2, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tpks, err := tkh.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := tpks[0].Encrypt(b(42), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var tests = map[string]struct {
+		k              *big.Int
+		expectedIsZero bool
+	}{
+		"equal": {
+			k:              b(42),
+			expectedIsZero: true,
+		},
+		"not equal": {
+			k:              b(41),
+			expectedIsZero: false,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			prepared, err := tpks[0].PrepareEqualityToConstant(c, test.k, rand.Reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if prepared.C.Cmp(c.C) == 0 {
+				t.Fatal("prepared cypher should be rerandomized")
+			}
+
+			message, err := ThresholdDecrypt(tpks, prepared)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if isZero := message.Sign() == 0; isZero != test.expectedIsZero {
+				t.Errorf(
+					"Unexpected decrypted value [%v] for constant [%v]",
+					message,
+					test.k,
+				)
+			}
+		})
+	}
+}
+
+func TestCombineCostEstimate(t *testing.T) {
+	var tests = map[string]struct {
+		servers   int
+		threshold int
+	}{
+		"1 of 2": {
+			servers:   2,
+			threshold: 1,
+		},
+		"3 of 5": {
+			servers:   5,
+			threshold: 3,
+		},
+		"6 of 10": {
+			servers:   10,
+			threshold: 6,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			tkh, err := GetThresholdKeyGenerator(32, test.servers, test.threshold, rand.Reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tpks, err := tkh.Generate()
+			if err != nil {
+				t.Fatal(err)
+			}
+			tk := tpks[0].getThresholdKey()
+
+			c, err := tk.Encrypt(b(42), rand.Reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+			shares := make([]*PartialDecryption, test.threshold)
+			for i := range shares {
+				shares[i] = tpks[i].Decrypt(c.C)
+			}
+			expected, err := tk.CombinePartialDecryptions(shares)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Every share is raised to its Lagrange coefficient in one
+			// exponentiation. A share which has been exponentiated changes
+			// the combined message when multiplied by N+1, so counting such
+			// shares counts the exponentiations actually performed.
+			exponentiations := 0
+			for i := range shares {
+				tampered := make([]*PartialDecryption, len(shares))
+				copy(tampered, shares)
+				tampered[i] = &PartialDecryption{
+					Id: shares[i].Id,
+					Decryption: new(big.Int).Mod(
+						new(big.Int).Mul(shares[i].Decryption, new(big.Int).Add(tk.N, ONE)),
+						tk.GetNSquare(),
+					),
+				}
+				message, err := tk.CombinePartialDecryptions(tampered)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if message.Cmp(expected) != 0 {
+					exponentiations++
+				}
+			}
+
+			estimate := tk.CombineCostEstimate()
+			if estimate != exponentiations {
+				t.Errorf(
+					"Unexpected combine cost estimate\nExpected: %v\nActual: %v",
+					exponentiations,
+					estimate,
+				)
+			}
+		})
+	}
+}
+
+func TestCombinePartialDecryptionsZKPFromAnotherKeySet(t *testing.T) {
+	generate := func() []*ThresholdPrivateKey {
+		tkh, err := GetThresholdKeyGenerator(32, 3, 2, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tpks, err := tkh.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tpks
+	}
+	tpks := generate()
+	otherTpks := generate()
+
+	c, err := tpks[0].Encrypt(b(100), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	share1, err := tpks[0].DecryptAndProduceZKP(c.C, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	share2, err := otherTpks[1].DecryptAndProduceZKP(c.C, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	share3, err := tpks[2].DecryptAndProduceZKP(c.C, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = tpks[0].CombinePartialDecryptionsZKP(
+		[]*PartialDecryptionZKP{share1, share2, share3},
+	)
+	expectedError := errors.New(
+		"partial decryption of server 2 has been produced with another key",
+	)
+	if !reflect.DeepEqual(expectedError, err) {
+		t.Errorf(
+			"Unexpected error\nExpected: %v\nActual: %v",
+			expectedError,
+			err,
+		)
+	}
+}
+
 func TestCombinePartialDecryptionsWith100Shares(t *testing.T) {
 	tkh, err := GetThresholdKeyGenerator(32, 100, 50, rand.Reader)
 	if err != nil {
@@ -361,6 +950,117 @@ func TestCombinePartialDecryptionsWith100Shares(t *testing.T) {
 	}
 }
 
+func TestVerificationProductCheck(t *testing.T) {
+	tkh, err := GetThresholdKeyGenerator(32, 5, 3, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tpks, err := tkh.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := tpks[0].Encrypt(b(100), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	shares := make([]*PartialDecryptionZKP, 3)
+	for i := range shares {
+		if shares[i], err = tpks[i].DecryptAndProduceZKP(c.C, rand.Reader); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := tpks[0].VerificationProductCheck(shares); err != nil {
+		t.Errorf(
+			"Unexpected error\nExpected: %v\nActual: %v",
+			nil,
+			err,
+		)
+	}
+
+	corrupted := *shares[1]
+	corrupted.Z = new(big.Int).Add(corrupted.Z, ONE)
+	shares[1] = &corrupted
+
+	expectedError := fmt.Errorf(
+		"partial decryptions of servers [%v] failed verification",
+		corrupted.Id,
+	)
+	err = tpks[0].VerificationProductCheck(shares)
+	if !reflect.DeepEqual(expectedError, err) {
+		t.Errorf(
+			"Unexpected error\nExpected: %v\nActual: %v",
+			expectedError,
+			err,
+		)
+	}
+}
+
+func TestVerificationProductCheckRejectsIncompleteShares(t *testing.T) {
+	tpk := getThresholdPrivateKey()
+	c, err := tpk.Encrypt(b(100), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	share, err := tpk.DecryptAndProduceZKP(c.C, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	withoutC := *share
+	withoutC.C = nil
+	withoutDecryption := *share
+	withoutDecryption.Decryption = nil
+	withoutE := *share
+	withoutE.E = nil
+	withoutZ := *share
+	withoutZ.Z = nil
+
+	incomplete := fmt.Errorf(
+		"partial decryption of server %v is incomplete",
+		share.Id,
+	)
+	var tests = map[string]struct {
+		shares        []*PartialDecryptionZKP
+		expectedError error
+	}{
+		"nil share": {
+			shares:        []*PartialDecryptionZKP{share, nil},
+			expectedError: errors.New("partial decryption 1 is nil"),
+		},
+		"nil C": {
+			shares:        []*PartialDecryptionZKP{&withoutC, share},
+			expectedError: incomplete,
+		},
+		"nil decryption": {
+			shares:        []*PartialDecryptionZKP{share, &withoutDecryption},
+			expectedError: incomplete,
+		},
+		"nil E": {
+			shares:        []*PartialDecryptionZKP{&withoutE},
+			expectedError: incomplete,
+		},
+		"nil Z": {
+			shares:        []*PartialDecryptionZKP{&withoutZ},
+			expectedError: incomplete,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			err := tpk.VerificationProductCheck(test.shares)
+			if !reflect.DeepEqual(test.expectedError, err) {
+				t.Errorf(
+					"Unexpected error\nExpected: %v\nActual: %v",
+					test.expectedError,
+					err,
+				)
+			}
+		})
+	}
+}
+
 func TestVerifyDecryption(t *testing.T) {
 	tkh, err := GetThresholdKeyGenerator(32, 2, 2, rand.Reader)
 	if err != nil {
@@ -378,11 +1078,11 @@ func TestVerifyDecryption(t *testing.T) {
 	if err != nil {
 		t.Error(err)
 	}
-	pd1, err := tpks[0].DecryptAndProduceZNP(cypher.C, rand.Reader)
+	pd1, err := tpks[0].DecryptAndProduceZKP(cypher.C, rand.Reader)
 	if err != nil {
 		t.Error(err)
 	}
-	pd2, err := tpks[1].DecryptAndProduceZNP(cypher.C, rand.Reader)
+	pd2, err := tpks[1].DecryptAndProduceZKP(cypher.C, rand.Reader)
 	if err != nil {
 		t.Error(err)
 	}
@@ -401,3 +1101,249 @@ func TestVerifyDecryption(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestThresholdDecrypt(t *testing.T) {
+	tkh, err := GetThresholdKeyGenerator(32, 5, 3, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tpks, err := tkh.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := tpks[0].Encrypt(b(42), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if message, err := ThresholdDecrypt(tpks, c); err != nil {
+		t.Error(err)
+	} else if n(message) != 42 {
+		t.Error("decrypted message was not 42 but ", message)
+	}
+
+	if _, err := ThresholdDecrypt(tpks[:2], c); err == nil {
+		t.Error("expected error for too few keys")
+	}
+	if _, err := ThresholdDecrypt(nil, c); err == nil {
+		t.Error("expected error for no keys")
+	}
+
+	for _, threshold := range []int{0, -1} {
+		key := *tpks[0]
+		key.Threshold = threshold
+		if _, err := ThresholdDecrypt([]*ThresholdPrivateKey{&key}, c); err == nil {
+			t.Errorf("expected error for threshold %v", threshold)
+		}
+	}
+}
+
+func TestThresholdPrivateKeyInfo(t *testing.T) {
+	key := getThresholdPrivateKey()
+
+	expected := ThresholdKeyInfo{
+		Id:                             key.Id,
+		Threshold:                      key.Threshold,
+		TotalNumberOfDecryptionServers: key.TotalNumberOfDecryptionServers,
+		BitLength:                      key.N.BitLen(),
+		Fingerprint:                    key.Fingerprint(),
+	}
+	info := key.Info()
+	if !reflect.DeepEqual(expected, info) {
+		t.Errorf(
+			"Unexpected key info\nExpected: %+v\nActual: %+v",
+			expected,
+			info,
+		)
+	}
+
+	// no field can hold the share, whatever its name
+	infoType := reflect.TypeOf(info)
+	for i := 0; i < infoType.NumField(); i++ {
+		if infoType.Field(i).Type == reflect.TypeOf(key.Share) {
+			t.Errorf("key info field %v can hold the share", infoType.Field(i).Name)
+		}
+	}
+}
+
+func TestZeroizeThresholdPrivateKey(t *testing.T) {
+	key := new(ThresholdPrivateKey)
+	key.Share = b(862)
+	share := key.Share
+
+	key.Zeroize()
+
+	if share.Sign() != 0 {
+		t.Error("share has not been zeroized ", share)
+	}
+}
+
+func getBenchmarkThresholdPrivateKeys(b *testing.B) []*ThresholdPrivateKey {
+	tkh, err := GetThresholdKeyGenerator(*benchmarkBitLength, 5, 3, rand.Reader)
+	if err != nil {
+		b.Fatal(err)
+	}
+	tpks, err := tkh.Generate()
+	if err != nil {
+		b.Fatal(err)
+	}
+	return tpks
+}
+
+func BenchmarkThresholdDecrypt(b *testing.B) {
+	tpks := getBenchmarkThresholdPrivateKeys(b)
+	c, err := tpks[0].Encrypt(big.NewInt(123456), rand.Reader)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		tpks[0].Decrypt(c.C)
+	}
+}
+
+func BenchmarkCombinePartialDecryptions(b *testing.B) {
+	tpks := getBenchmarkThresholdPrivateKeys(b)
+	c, err := tpks[0].Encrypt(big.NewInt(123456), rand.Reader)
+	if err != nil {
+		b.Fatal(err)
+	}
+	shares := make([]*PartialDecryption, len(tpks))
+	for i, tpk := range tpks {
+		shares[i] = tpk.Decrypt(c.C)
+	}
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := tpks[0].CombinePartialDecryptions(shares); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkCombine50Of100PartialDecryptions(b *testing.B) {
+	tkh, err := GetThresholdKeyGenerator(*benchmarkBitLength, 100, 50, rand.Reader)
+	if err != nil {
+		b.Fatal(err)
+	}
+	tpks, err := tkh.Generate()
+	if err != nil {
+		b.Fatal(err)
+	}
+	c, err := tpks[0].Encrypt(big.NewInt(123456), rand.Reader)
+	if err != nil {
+		b.Fatal(err)
+	}
+	shares := make([]*PartialDecryption, 50)
+	for i := range shares {
+		shares[i] = tpks[i].Decrypt(c.C)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := tpks[0].CombinePartialDecryptions(shares); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func benchmarkDecryptAndProduceZKP(
+	b *testing.B,
+	produce func(*ThresholdPrivateKey, *big.Int) (*PartialDecryptionZKP, error),
+) {
+	tkh, err := GetThresholdKeyGenerator(*benchmarkBitLength, 100, 50, rand.Reader)
+	if err != nil {
+		b.Fatal(err)
+	}
+	tpks, err := tkh.Generate()
+	if err != nil {
+		b.Fatal(err)
+	}
+	c, err := tpks[0].Encrypt(big.NewInt(123456), rand.Reader)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := produce(tpks[0], c.C); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecryptAndProduceZKP(b *testing.B) {
+	benchmarkDecryptAndProduceZKP(
+		b,
+		func(tpk *ThresholdPrivateKey, c *big.Int) (*PartialDecryptionZKP, error) {
+			return tpk.DecryptAndProduceZKP(c, rand.Reader)
+		},
+	)
+}
+
+func BenchmarkDecryptAndProduceZKPSharedKey(b *testing.B) {
+	benchmarkDecryptAndProduceZKP(
+		b,
+		func(tpk *ThresholdPrivateKey, c *big.Int) (*PartialDecryptionZKP, error) {
+			return tpk.DecryptAndProduceZKPSharedKey(c, nil, rand.Reader)
+		},
+	)
+}
+
+func TestVerifyOwnVi(t *testing.T) {
+	tkh, err := GetThresholdKeyGenerator(32, 4, 3, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tpks, err := tkh.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tpk := range tpks {
+		if !tpk.VerifyOwnVi() {
+			t.Errorf("Vi of server %v should be valid", tpk.Id)
+		}
+	}
+
+	tpks[1].Vi[1] = new(big.Int).Add(tpks[1].Vi[1], b(1))
+	if tpks[1].VerifyOwnVi() {
+		t.Error("tampered Vi should not be valid")
+	}
+
+	tpks[2].Id = 5
+	if tpks[2].VerifyOwnVi() {
+		t.Error("Vi should not be valid for an out of range id")
+	}
+}
+
+func TestReconstructThresholdPublicKey(t *testing.T) {
+	tkh, err := GetThresholdKeyGenerator(32, 4, 3, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tpks, err := tkh.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pk, err := ReconstructThresholdPublicKey(tpks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pk.Equal(&tpks[0].ThresholdPublicKey) {
+		t.Error("reconstructed key is not equal to the embedded one")
+	}
+
+	tpks[2].N = new(big.Int).Add(tpks[2].N, b(2))
+	if _, err := ReconstructThresholdPublicKey(tpks); err == nil {
+		t.Error("expected an error for a tampered N")
+	}
+
+	if _, err := ReconstructThresholdPublicKey(nil); err == nil {
+		t.Error("expected an error for no keys")
+	}
+}
@@ -2,27 +2,103 @@ package paillier
 
 import (
 	"crypto/rand"
+	"errors"
 	"io"
 	"math/big"
+	"sync"
 )
 
+// RandReader is the source of randomness accepted by all the functions of the
+// package which consume randomness, e.g. encryption, zero-knowledge proofs,
+// safe prime generation and threshold key generation. Any io.Reader, such as
+// rand.Reader from the package crypto/rand, satisfies it. It can be wrapped,
+// for instance, to count or log every draw of randomness during a security
+// review.
+type RandReader interface {
+	io.Reader
+}
+
 var ZERO = big.NewInt(0)
 var ONE = big.NewInt(1)
 var TWO = big.NewInt(2)
 var FOUR = big.NewInt(4)
 
+// Previously computed factorials keyed by n. Factorial is evaluated
+// repeatedly with the same n as `delta` of threshold keys.
+var factorials = struct {
+	sync.Mutex
+	values map[int]*big.Int
+}{values: make(map[int]*big.Int)}
+
 //  returns n! = n*(n-1)*(n-2)...3*2*1
+//
+// Results are memoized; the returned value is a copy and can be freely
+// modified by the caller.
 func Factorial(n int) *big.Int {
+	factorials.Lock()
+	defer factorials.Unlock()
+
+	ret, ok := factorials.values[n]
+	if !ok {
+		ret = computeFactorial(n)
+		factorials.values[n] = ret
+	}
+	return new(big.Int).Set(ret)
+}
+
+func computeFactorial(n int) *big.Int {
 	ret := big.NewInt(1)
-	for i := 1; i <= n; i++ {
-		ret = new(big.Int).Mul(ret, big.NewInt(int64(i)))
+	i := new(big.Int)
+	for k := int64(2); k <= int64(n); k++ {
+		ret.Mul(ret, i.SetInt64(k))
 	}
 	return ret
 }
 
+// ErrNotInvertible is returned when a number has no multiplicative inverse
+// modulo the modulus, that is when gcd(number, modulus) != 1.
+var ErrNotInvertible = errors.New("number is not invertible modulo the modulus")
+
+// Returns the multiplicative inverse of `a` modulo `m` or `ErrNotInvertible`
+// if gcd(a, m) != 1. Unlike big.Int.ModInverse which returns nil in such
+// a case, the failure can't go unnoticed and end with a nil pointer panic.
+func mustInverse(a, m *big.Int) (*big.Int, error) {
+	inverse := new(big.Int).ModInverse(a, m)
+	if inverse == nil {
+		return nil, ErrNotInvertible
+	}
+	return inverse, nil
+}
+
+// ModExp computes a^b mod c. Unlike big.Int.Exp, it accepts a negative exponent
+// in which case the multiplicative inverse of a^|b| modulo c is returned:
+//
+// a^{-b} = (a^b)^{-1} mod c
+//
+// An error is returned if b is negative and a is not invertible modulo c.
+func ModExp(a, b, c *big.Int) (*big.Int, error) {
+	if b.Cmp(ZERO) == -1 { // b < 0 ?
+		ret := new(big.Int).Exp(a, new(big.Int).Neg(b), c)
+		return mustInverse(ret, c)
+	}
+	return new(big.Int).Exp(a, b, c), nil
+}
+
+// Overwrites the internal words of `x` with zeros and sets `x` to zero.
+func zeroize(x *big.Int) {
+	if x == nil {
+		return
+	}
+	words := x.Bits()
+	for i := range words {
+		words[i] = 0
+	}
+	x.SetInt64(0)
+}
+
 // Generate a random element in the group of all the elements in Z/nZ that
 // has a multiplicative inverse.
-func GetRandomNumberInMultiplicativeGroup(n *big.Int, random io.Reader) (*big.Int, error) {
+func GetRandomNumberInMultiplicativeGroup(n *big.Int, random RandReader) (*big.Int, error) {
 	r, err := rand.Int(random, n)
 	if err != nil {
 		return nil, err
@@ -39,10 +115,54 @@ func GetRandomNumberInMultiplicativeGroup(n *big.Int, random io.Reader) (*big.In
 //  Return a random generator of RQn with high probability.  THIS METHOD
 //  ONLY WORKS IF N IS THE PRODUCT OF TWO SAFE PRIMES! This heuristic is used
 //  threshold signature paper in the Victor Shoup
-func GetRandomGeneratorOfTheQuadraticResidue(n *big.Int, rand io.Reader) (*big.Int, error) {
+func GetRandomGeneratorOfTheQuadraticResidue(n *big.Int, rand RandReader) (*big.Int, error) {
 	r, err := GetRandomNumberInMultiplicativeGroup(n, rand)
 	if err != nil {
 		return nil, err
 	}
 	return new(big.Int).Mod(new(big.Int).Mul(r, r), n), nil
 }
+
+// GetStrongGeneratorOfQR returns a random generator of the quadratic
+// residues modulo `n`. Like `GetRandomGeneratorOfTheQuadraticResidue` it
+// only works if `n` is a product of two safe primes p = 2p' + 1 and
+// q = 2q' + 1, but it never returns an element of small order.
+//
+// QRn is then cyclic of order p'q' and a random square v generates it unless
+// v = 1 mod p or v = 1 mod q, in which case its order is only q', p' or 1.
+// Such squares are rejected by checking gcd(v - 1, n) = 1, without knowing
+// the factorization of `n`, and a new one is drawn. Because every square has
+// the same number of square roots, the returned generator is uniformly
+// distributed among all the generators of QRn. The rejected fraction is
+// about 1/p' + 1/q', negligible for keys of recommended sizes, but the
+// check makes the soundness of the ZKP using the generator unconditional.
+func GetStrongGeneratorOfQR(n *big.Int, rand RandReader) (*big.Int, error) {
+	for {
+		v, err := GetRandomGeneratorOfTheQuadraticResidue(n, rand)
+		if err != nil {
+			return nil, err
+		}
+		vMinusOne := new(big.Int).Sub(v, ONE)
+		if new(big.Int).GCD(nil, nil, vMinusOne, n).Cmp(ONE) == 0 {
+			return v, nil
+		}
+	}
+}
+
+// ValidateGenerator performs a sanity check of `v` being a generator of the
+// quadratic residues modulo `n` as returned by
+// `GetRandomGeneratorOfTheQuadraticResidue`. It rejects `v` out of the range
+// (1, n), not invertible modulo `n` or of obviously small order, that is `v`
+// such that `v^2 = 1 mod n`.
+func ValidateGenerator(v, n *big.Int) bool {
+	if v.Cmp(ONE) != 1 || v.Cmp(n) != -1 { // v <= 1 || v >= n ?
+		return false
+	}
+	if new(big.Int).GCD(nil, nil, v, n).Cmp(ONE) != 0 {
+		return false
+	}
+	if new(big.Int).Exp(v, TWO, n).Cmp(ONE) == 0 {
+		return false
+	}
+	return true
+}
@@ -53,10 +53,92 @@ func TestGetRandomNumberInMultiplicativeGroup(t *testing.T) {
 	}
 }
 
+func TestMustInverse(t *testing.T) {
+	var tests = map[string]struct {
+		a               *big.Int
+		m               *big.Int
+		expectedInverse *big.Int
+		expectedError   error
+	}{
+		"invertible": {
+			a:               b(3),
+			m:               b(7),
+			expectedInverse: b(5),
+		},
+		"not invertible": {
+			a:             b(14),
+			m:             b(49),
+			expectedError: ErrNotInvertible,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			inverse, err := mustInverse(test.a, test.m)
+			if err != test.expectedError {
+				t.Fatalf(
+					"Unexpected error\nExpected: %v\nActual: %v",
+					test.expectedError,
+					err,
+				)
+			}
+			if test.expectedInverse != nil && test.expectedInverse.Cmp(inverse) != 0 {
+				t.Errorf(
+					"Unexpected inverse\nExpected: %v\nActual: %v",
+					test.expectedInverse,
+					inverse,
+				)
+			}
+		})
+	}
+}
+
+func TestModExp(t *testing.T) {
+	if exp, err := ModExp(b(720), b(10), b(49)); err != nil || 43 != n(exp) {
+		t.Error("Unexpected exponent. Expected 43 but got", exp, err)
+	}
+
+	if exp, err := ModExp(b(720), b(0), b(49)); err != nil || 1 != n(exp) {
+		t.Error("Unexpected exponent. Expected 1 but got", exp, err)
+	}
+
+	if exp, err := ModExp(b(720), b(-10), b(49)); err != nil || 8 != n(exp) {
+		t.Error("Unexpected exponent. Expected 8 but got", exp, err)
+	}
+
+	if _, err := ModExp(b(14), b(-3), b(49)); err == nil {
+		t.Error("Expected an error for a base not invertible modulo 49")
+	}
+}
+
 func TestFactorial(t *testing.T) {
 	if delta := Factorial(6); 720 != delta.Int64() {
 		t.Error("Delta is not 720 but", delta)
 	}
+
+	// the memoized value must not be affected by the caller
+	Factorial(6).SetInt64(1)
+	if delta := Factorial(6); 720 != delta.Int64() {
+		t.Error("Delta is not 720 but", delta)
+	}
+
+	if delta := Factorial(0); 1 != delta.Int64() {
+		t.Error("Delta is not 1 but", delta)
+	}
+}
+
+func BenchmarkFactorial500(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		Factorial(500)
+	}
+}
+
+func BenchmarkComputeFactorial500(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		computeFactorial(500)
+	}
 }
 
 // IsSafePrime checks whether `p` is a safe prime. A safe prime is a prime
@@ -105,3 +187,56 @@ func TestGetRandomGeneratorOfTheQuadraticResidue(t *testing.T) {
 	}
 
 }
+
+func TestGetStrongGeneratorOfQR(t *testing.T) {
+	// p = 2*11 + 1, q = 2*23 + 1, QRn is cyclic of order 11*23 and has
+	// (11-1)*(23-1) generators
+	p1, q1 := b(11), b(23)
+	m := b(23 * 47)
+	order := new(big.Int).Mul(p1, q1)
+	generators := make(map[int]int)
+
+	for i := 0; i < 2000; i++ {
+		v, err := GetStrongGeneratorOfQR(m, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if new(big.Int).Exp(v, order, m).Cmp(ONE) != 0 {
+			t.Fatal("not a quadratic residue ", v)
+		}
+		if new(big.Int).Exp(v, p1, m).Cmp(ONE) == 0 ||
+			new(big.Int).Exp(v, q1, m).Cmp(ONE) == 0 {
+			t.Fatal("generator of small order ", v)
+		}
+		generators[n(v)]++
+	}
+
+	// every generator is drawn 9 times on average, missing more than a few
+	// of them means the distribution is not uniform
+	if len(generators) < 210 {
+		t.Errorf(
+			"Unexpected number of distinct generators\nExpected: at least %v\nActual: %v",
+			210,
+			len(generators),
+		)
+	}
+}
+
+func TestValidateGenerator(t *testing.T) {
+	m := b(347 * 359)
+	for _, v := range []*big.Int{b(0), b(1), m, b(347), new(big.Int).Sub(m, ONE)} {
+		if ValidateGenerator(v, m) {
+			t.Error("generator should be rejected ", v)
+		}
+	}
+
+	for i := 0; i < 100; i++ {
+		v, err := GetRandomGeneratorOfTheQuadraticResidue(m, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v.Cmp(ONE) != 0 && !ValidateGenerator(v, m) {
+			t.Error("generator should be accepted ", v)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package paillier
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// VotePacker packs the tallies of all candidates of an election into a single
+// plaintext, as described in [DJN 10], section 6.
+//
+// A vote for the candidate `i` is encoded as `(n+1)^i`, where `n` is the
+// maximum number of voters. Since there can't be more than `n` votes for any
+// candidate, the sum of all the encoded votes is a number whose base `(n+1)`
+// digits are the tallies of the candidates. Thanks to the homomorphic
+// property, the encrypted votes can be summed with `PublicKey.Add` and only
+// the final result needs to be decrypted.
+//
+// The packing is possible only if `(n+1)^k < N`, where `k` is the number of
+// candidates and `N` is the public key modulus.
+//
+//     [DJN 10]: Ivan Damgard, Mads Jurik, Jesper Buus Nielsen, (2010)
+//               A Generalization of Paillier’s Public-Key System
+//               with Applications to Electronic Voting
+//               Aarhus University, Dept. of Computer Science, BRICS
+type VotePacker struct {
+	MaxVoters  int
+	Candidates int
+
+	base *big.Int // base = MaxVoters + 1
+}
+
+// GetVotePacker constructs the VotePacker for an election with at most
+// `maxVoters` voters and `candidates` candidates. An error is returned if the
+// tallies can not be packed in the plaintext space of the public key.
+func GetVotePacker(publicKey *PublicKey, maxVoters, candidates int) (*VotePacker, error) {
+	if maxVoters < 1 || candidates < 1 {
+		return nil, errors.New("there must be at least one voter and one candidate")
+	}
+
+	base := big.NewInt(int64(maxVoters) + 1)
+	capacity := new(big.Int).Exp(base, big.NewInt(int64(candidates)), nil)
+	if capacity.Cmp(publicKey.N) != -1 {
+		return nil, fmt.Errorf(
+			"%v candidates and %v voters do not fit in the plaintext space [0, %v)",
+			candidates,
+			maxVoters,
+			publicKey.N,
+		)
+	}
+
+	return &VotePacker{
+		MaxVoters:  maxVoters,
+		Candidates: candidates,
+		base:       base,
+	}, nil
+}
+
+// EncodeVote returns the plaintext of a vote for the candidate with index
+// `candidate`, that is `(n+1)^candidate`. Candidates are indexed from 0.
+func (vp *VotePacker) EncodeVote(candidate int) (*big.Int, error) {
+	if candidate < 0 || candidate >= vp.Candidates {
+		return nil, fmt.Errorf(
+			"candidate %v is out of allowed range [0, %v)",
+			candidate,
+			vp.Candidates,
+		)
+	}
+	return new(big.Int).Exp(vp.base, big.NewInt(int64(candidate)), nil), nil
+}
+
+// Unpack splits the decrypted sum of all the votes into the tallies of the
+// candidates. The tally of the candidate `i` is at index `i`.
+func (vp *VotePacker) Unpack(tally *big.Int) ([]int, error) {
+	if tally.Sign() == -1 {
+		return nil, errors.New("tally can not be negative")
+	}
+
+	counts := make([]int, vp.Candidates)
+	remaining := new(big.Int).Set(tally)
+	digit := new(big.Int)
+	for i := range counts {
+		remaining.DivMod(remaining, vp.base, digit)
+		counts[i] = int(digit.Int64())
+	}
+	if remaining.Sign() != 0 {
+		return nil, errors.New("tally exceeds the packing capacity")
+	}
+	return counts, nil
+}
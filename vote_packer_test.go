@@ -0,0 +1,66 @@
+package paillier
+
+import (
+	"crypto/rand"
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+func TestVotePackerElection(t *testing.T) {
+	privateKey := CreatePrivateKey(big.NewInt(463), big.NewInt(631))
+
+	packer, err := GetVotePacker(&privateKey.PublicKey, 5, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	votes := []int{0, 2, 2, 1, 2}
+	encrypted := make([]*Cypher, len(votes))
+	for i, vote := range votes {
+		m, err := packer.EncodeVote(vote)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if encrypted[i], err = privateKey.Encrypt(m, rand.Reader); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tally := privateKey.Decrypt(privateKey.Add(encrypted...))
+	counts, err := packer.Unpack(tally)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []int{1, 1, 3}
+	if !reflect.DeepEqual(expected, counts) {
+		t.Errorf(
+			"Unexpected tallies\nExpected: %v\nActual: %v",
+			expected,
+			counts,
+		)
+	}
+}
+
+func TestVotePackerValidation(t *testing.T) {
+	// N = 143
+	publicKey := &PublicKey{N: big.NewInt(143)}
+
+	// 12^2 = 144 >= 143
+	if _, err := GetVotePacker(publicKey, 11, 2); err == nil {
+		t.Error("expected an error for tallies not fitting in N")
+	}
+
+	// 11^2 = 121 < 143
+	packer, err := GetVotePacker(publicKey, 10, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := packer.EncodeVote(2); err == nil {
+		t.Error("expected an error for candidate out of range")
+	}
+	if _, err := packer.Unpack(big.NewInt(121)); err == nil {
+		t.Error("expected an error for tally exceeding the capacity")
+	}
+}